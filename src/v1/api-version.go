@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"strings"
+)
+
+// Version of the routes of the Gen-API service, when none is specified.
+const defaultAPIVersion = "v1"
+
+// WithAPIVersion sends the log line calls to the routes of the given version of the Gen-API service, for example
+// "/api/v2/log-lines" for "v2". It defaults to "v1". The ping route is not versioned, and is not affected.
+//
+// Only use this option with versions whose requests and responses have the same shape as v1. The v2 package wraps
+// this client for the v2 routes.
+func WithAPIVersion(version string) Option {
+	return func(cfg *config) {
+		cfg.apiVersion = strings.Trim(version, "/")
+	}
+}
+
+// Returns the path of a route of the configured version of the Gen-API service.
+func (cfg *config) apiPath(route string) string {
+	return "/api/" + cfg.apiVersion + route
+}
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"net/http"
+)
+
+// Name of the header carrying the API key, when none is specified.
+const defaultAPIKeyHeader = "X-API-Key"
+
+// TokenSource returns the bearer token to authenticate a request with. It is called once per request, so rotating
+// tokens are always up-to-date.
+type TokenSource func(ctx context.Context) (string, error)
+
+// WithBearerToken authenticates every request with the given token, using the "Authorization: Bearer" header.
+func WithBearerToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource authenticates every request with a bearer token retrieved from the given source. If the source
+// fails, the call is aborted with its error, and no request is sent.
+func WithTokenSource(source TokenSource) Option {
+	return func(cfg *config) {
+		cfg.tokenSource = source
+	}
+}
+
+// WithOAuth2 authenticates every request with a bearer token retrieved from the given OAuth2 source, like the ones of
+// the golang.org/x/oauth2/clientcredentials package. The source is asked for a token before each request, so it
+// should cache its tokens, and refresh them once expired (see oauth2.ReuseTokenSource).
+//
+// If the source fails, the call is aborted with its error. Fetching a token stops waiting for the source once the
+// context of the call is done.
+func WithOAuth2(source oauth2.TokenSource) Option {
+	return WithTokenSource(func(ctx context.Context) (string, error) {
+		type result struct {
+			token *oauth2.Token
+			err   error
+		}
+
+		// Token does not take a context: run it in the background, so the call can give up on it.
+		results := make(chan result, 1)
+		go func() {
+			token, err := source.Token()
+			results <- result{token: token, err: err}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case res := <-results:
+			if res.err != nil {
+				return "", res.err
+			}
+
+			if !res.token.Valid() {
+				return "", errors.New("invalid OAuth2 token")
+			}
+
+			return res.token.AccessToken, nil
+		}
+	})
+}
+
+// Key of the bearer token override in a context.
+type authTokenKey struct{}
+
+// WithAuthToken authenticates the calls made with this context with the given bearer token, in place of the bearer
+// authentication of the client. This lets a single client make calls on behalf of different users.
+//
+// The API key of the client, if any, is still sent (see WithAPIKey).
+func WithAuthToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, authTokenKey{}, token)
+}
+
+// Returns the bearer token override of the context, if any.
+func authTokenOf(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(authTokenKey{}).(string)
+	return token, ok && token != ""
+}
+
+// WithAPIKey authenticates every request with a static key, sent in the given header. The header defaults to
+// X-API-Key when empty.
+//
+// This option can be combined with bearer authentication, for environments that require both.
+func WithAPIKey(header, value string) Option {
+	return func(cfg *config) {
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+
+		cfg.apiKeyHeader = header
+		cfg.apiKey = value
+	}
+}
+
+// WithAuthRetry sends a request again, once, when the Gen-API service rejects it with a 401 status, in case its bearer
+// token expired in flight. The token source (see WithTokenSource and WithOAuth2) is asked for a new token for the
+// second request, so it should not return the rejected token again.
+//
+// This retry comes on top of the attempts of WithRetry. It has no effect without bearer authentication.
+func WithAuthRetry() Option {
+	return func(cfg *config) {
+		cfg.authRetry = true
+	}
+}
+
+// Tells whether a request rejected with a 401 status can be sent again with a new token.
+func (cfg *config) shouldRetryAuth(ctx context.Context) bool {
+	// The token of the context cannot be refreshed: sending it again would get the same answer.
+	if _, ok := authTokenOf(ctx); ok {
+		return false
+	}
+
+	return cfg.authRetry && cfg.tokenSource != nil && ctx.Err() == nil
+}
+
+// Sets the authentication headers of the request, based on the configuration and the context of the request.
+func (cfg *config) authenticate(req *http.Request) error {
+	if cfg.apiKeyHeader != "" {
+		req.Header.Set(cfg.apiKeyHeader, cfg.apiKey)
+	}
+
+	if token, ok := authTokenOf(req.Context()); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	if cfg.tokenSource == nil {
+		return nil
+	}
+
+	token, err := cfg.tokenSource(req.Context())
+	if err != nil {
+		return fmt.Errorf("retrieve bearer token: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes how long to wait for before retrying a failed attempt. Implementations must be safe for
+// concurrent use, since the retries of concurrent calls share them.
+type Backoff interface {
+	// Next returns the delay before the given retry. The attempt is zero-based: 0 is the delay before the second
+	// attempt of a call.
+	Next(attempt int) time.Duration
+}
+
+// WithBackoff replaces the strategy computing the delay between two attempts, when retries are enabled (see
+// WithRetry). It defaults to an ExponentialBackoff, starting at the base delay of WithRetry.
+//
+// A 429 response advertising a Retry-After delay is still waited for as advertised.
+func WithBackoff(backoff Backoff) Option {
+	return func(cfg *config) {
+		cfg.retry.backoff = backoff
+	}
+}
+
+// ExponentialBackoff waits for Base * 2^attempt, with some jitter, and up to Max if not zero.
+type ExponentialBackoff struct {
+	// Delay before the first retry.
+	Base time.Duration
+	// Longest delay between two attempts. Delays are not capped if zero.
+	Max time.Duration
+}
+
+func (backoff ExponentialBackoff) Next(attempt int) time.Duration {
+	// Cap the exponent, so the delay does not overflow on absurdly high attempt counts.
+	delay := backoff.Base << min(attempt, 30)
+	if delay <= 0 {
+		return 0
+	}
+
+	if backoff.Max > 0 {
+		delay = min(delay, backoff.Max)
+	}
+
+	// Equal jitter: wait at least half of the computed delay, so retries are still spaced out.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// ConstantBackoff always waits for the same delay.
+type ConstantBackoff time.Duration
+
+func (backoff ConstantBackoff) Next(int) time.Duration {
+	return time.Duration(backoff)
+}
+
+// DecorrelatedJitterBackoff waits for a random delay between Base and three times the upper bound of the previous
+// retry, up to Max if not zero. Delays grow as fast as with an ExponentialBackoff on average, but are spread much
+// more widely, so concurrent clients retrying the same failure do not stay in sync.
+//
+// The upper bound is derived from the attempt, rather than from the previous delay, so a single instance can be
+// shared by concurrent calls.
+type DecorrelatedJitterBackoff struct {
+	// Shortest delay between two attempts.
+	Base time.Duration
+	// Longest delay between two attempts. Delays are not capped if zero.
+	Max time.Duration
+}
+
+func (backoff DecorrelatedJitterBackoff) Next(attempt int) time.Duration {
+	if backoff.Base <= 0 {
+		return 0
+	}
+
+	upper := backoff.Base
+	for range min(attempt+1, 30) {
+		// Stop growing once capped, or before overflowing.
+		if (backoff.Max > 0 && upper >= backoff.Max) || upper > time.Duration(1<<62)/3 {
+			break
+		}
+
+		upper *= 3
+	}
+
+	if backoff.Max > 0 {
+		upper = min(upper, backoff.Max)
+	}
+
+	if upper <= backoff.Base {
+		return upper
+	}
+
+	return backoff.Base + rand.N(upper-backoff.Base+1)
+}
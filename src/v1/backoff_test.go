@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Returns the given delays, one per attempt, and records the attempts it is consulted for.
+type recordingBackoff struct {
+	mu sync.Mutex
+
+	delays   []time.Duration
+	attempts []int
+}
+
+func (backoff *recordingBackoff) Next(attempt int) time.Duration {
+	backoff.mu.Lock()
+	defer backoff.mu.Unlock()
+
+	backoff.attempts = append(backoff.attempts, attempt)
+
+	return backoff.delays[attempt]
+}
+
+func TestCustomBackoffIsConsultedPerAttempt(t *testing.T) {
+	server, sequence := newSequenceServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	clock := testutil.NewFakeClock(time.Now())
+	backoff := &recordingBackoff{delays: []time.Duration{time.Second, 5 * time.Second}}
+
+	api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Hour), WithBackoff(backoff), WithClock(clock))
+
+	errs := make(chan error, 1)
+
+	go func() {
+		_, _, err := api.Call(context.Background(), "an instruction", nil)
+		errs <- err
+	}()
+
+	// Each retry waits for the delay of the backoff, rather than the base delay of WithRetry.
+	for i, delay := range backoff.delays {
+		eventually(t, func() bool { return clock.Waiters() == 1 }, "the call is not waiting for its retry")
+
+		clock.Advance(delay - time.Millisecond)
+		if got := sequence.count(); got != i+1 {
+			t.Fatalf("expected attempt %d to wait for %s, got %d attempts", i+2, delay, got)
+		}
+
+		clock.Advance(time.Millisecond)
+		eventually(t, func() bool { return sequence.count() == i+2 }, "the call was not retried after the delay")
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	if !slices.Equal(backoff.attempts, []int{0, 1}) {
+		t.Errorf("expected the backoff to be consulted for attempts [0 1], got %v", backoff.attempts)
+	}
+}
+
+func TestBuiltInBackoffs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backoff  Backoff
+		attempt  int
+		min, max time.Duration
+	}{
+		{
+			name:    "Exponential/First",
+			backoff: ExponentialBackoff{Base: time.Second},
+			attempt: 0,
+			min:     500 * time.Millisecond,
+			max:     time.Second,
+		},
+		{
+			name:    "Exponential/Third",
+			backoff: ExponentialBackoff{Base: time.Second},
+			attempt: 2,
+			min:     2 * time.Second,
+			max:     4 * time.Second,
+		},
+		{
+			name:    "Exponential/Capped",
+			backoff: ExponentialBackoff{Base: time.Second, Max: 3 * time.Second},
+			attempt: 10,
+			min:     1500 * time.Millisecond,
+			max:     3 * time.Second,
+		},
+		{
+			name:    "Exponential/Huge",
+			backoff: ExponentialBackoff{Base: time.Second},
+			attempt: 1000,
+			min:     0,
+			max:     time.Duration(1<<63 - 1),
+		},
+		{name: "Constant", backoff: ConstantBackoff(time.Second), attempt: 5, min: time.Second, max: time.Second},
+		{
+			name:    "DecorrelatedJitter/First",
+			backoff: DecorrelatedJitterBackoff{Base: time.Second},
+			attempt: 0,
+			min:     time.Second,
+			max:     3 * time.Second,
+		},
+		{
+			name:    "DecorrelatedJitter/Capped",
+			backoff: DecorrelatedJitterBackoff{Base: time.Second, Max: 5 * time.Second},
+			attempt: 10,
+			min:     time.Second,
+			max:     5 * time.Second,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for range 100 {
+				delay := testCase.backoff.Next(testCase.attempt)
+				if delay < testCase.min || delay > testCase.max {
+					t.Fatalf("expected a delay between %s and %s, got %s", testCase.min, testCase.max, delay)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"errors"
+	"io"
+)
+
+// Maximum size of a response body, unless overridden with WithMaxResponseBytes.
+const defaultMaxResponseBytes int64 = 10 << 20 // 10 MiB
+
+// ErrResponseTooLarge is returned when the body of a response exceeds the limit set with WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseBytes limits the size of the response bodies read from the Gen-API service. Reading past this limit
+// fails with ErrResponseTooLarge.
+//
+// The limit defaults to 10 MiB. A zero or negative value disables it.
+func WithMaxResponseBytes(limit int64) Option {
+	return func(cfg *config) {
+		cfg.maxResponseBytes = limit
+	}
+}
+
+// A response body that fails once more than a given number of bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	// Number of bytes that can still be read.
+	remaining int64
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.remaining <= 0 {
+		// The limit is reached: the body is only too large if it has more data to give.
+		var probe [1]byte
+
+		n, err := body.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+
+		return 0, err
+	}
+
+	if int64(len(p)) > body.remaining {
+		p = p[:body.remaining]
+	}
+
+	n, err := body.ReadCloser.Read(p)
+	body.remaining -= int64(n)
+
+	return n, err
+}
+
+// Limits the size of the body, according to the configuration.
+func (cfg *config) limitBody(body io.ReadCloser) io.ReadCloser {
+	if cfg.maxResponseBytes <= 0 {
+		return body
+	}
+
+	return &limitedBody{ReadCloser: body, remaining: cfg.maxResponseBytes}
+}
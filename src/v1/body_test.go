@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Starts a server that streams a log line of the given length, in chunks, with the given status. If the status is
+// not 200, the body is the log line itself, as a plain text error message.
+func newStreamingServer(t *testing.T, status int, length int) *httptest.Server {
+	t.Helper()
+
+	chunk := strings.Repeat("x", 32<<10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		prefix, suffix := `{"logLine":"`, `"}`
+		if status != http.StatusOK {
+			prefix, suffix = "", ""
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(prefix))
+
+		for written := 0; written < length; written += len(chunk) {
+			if _, err := w.Write([]byte(chunk[:min(len(chunk), length-written)])); err != nil {
+				// The client gave up on the body.
+				return
+			}
+
+			w.(http.Flusher).Flush()
+		}
+
+		_, _ = w.Write([]byte(suffix))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	// Size of the JSON envelope around the log line.
+	const envelope = len(`{"logLine":""}`)
+
+	testCases := []struct {
+		name   string
+		opts   []Option
+		length int
+		// Whether the call fails with ErrResponseTooLarge.
+		tooLarge bool
+	}{
+		{name: "UnderLimit", opts: []Option{WithMaxResponseBytes(1024)}, length: 512},
+		{name: "AtLimit", opts: []Option{WithMaxResponseBytes(1024)}, length: 1024 - envelope},
+		{name: "OverLimit", opts: []Option{WithMaxResponseBytes(1024)}, length: 1024 - envelope + 1, tooLarge: true},
+		{name: "Streamed", opts: []Option{WithMaxResponseBytes(64 << 10)}, length: 1 << 20, tooLarge: true},
+		{name: "Default", length: int(defaultMaxResponseBytes), tooLarge: true},
+		{name: "Disabled", opts: []Option{WithMaxResponseBytes(0)}, length: int(defaultMaxResponseBytes)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newStreamingServer(t, http.StatusOK, testCase.length)
+
+			logLine, _, err := NewCreateLogLineAPI(server.URL, testCase.opts...).Call(
+				context.Background(), "an instruction", nil,
+			)
+			if testCase.tooLarge {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("expected ErrResponseTooLarge, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			if len(logLine) != testCase.length {
+				t.Errorf("expected a log line of %d bytes, got %d", testCase.length, len(logLine))
+			}
+		})
+	}
+}
+
+func TestMaxResponseBytesBoundsErrors(t *testing.T) {
+	server := newStreamingServer(t, http.StatusInternalServerError, 1<<20)
+
+	_, status, err := NewCreateLogLineAPI(server.URL, WithMaxResponseBytes(1024)).Call(
+		context.Background(), "an instruction", nil,
+	)
+	if err == nil || status != http.StatusInternalServerError {
+		t.Fatalf("expected a 500 error, got %d: %v", status, err)
+	}
+
+	// The message of the error is read from the body, up to the limit only.
+	if length := len(err.Error()); length > 2048 {
+		t.Errorf("expected the error to be bounded by the limit, got %d bytes", length)
+	}
+}
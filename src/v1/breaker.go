@@ -0,0 +1,232 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending any request, while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker stops sending requests to the Gen-API service after failures consecutive failed attempts. Calls
+// then fail right away with ErrCircuitOpen, for the cooldown duration. Use WithCircuitBreakerWindow to open the
+// breaker on a failure rate over a rolling window instead.
+//
+// Once the cooldown is over, a single probe request is let through: the breaker closes if it succeeds, or opens again
+// otherwise. A successful ping also closes the breaker.
+//
+// Transport errors and 5xx responses count as failures. The breaker is shared by all the APIs of a Client.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(cfg *config) {
+		if failures < 1 {
+			cfg.breaker = nil
+			return
+		}
+
+		cfg.breaker = &circuitBreaker{threshold: failures, cooldown: cooldown}
+	}
+}
+
+// WithCircuitBreakerWindow makes the circuit breaker (see WithCircuitBreaker) count the outcomes of the attempts over
+// a rolling window, rather than the consecutive failures. The breaker opens once, within the last window, the number
+// of failed attempts reaches the threshold of WithCircuitBreaker, and these failures make up at least failureRate of
+// the attempts, between 0 and 1. A success no longer resets the count: failures expire with the window.
+//
+// It has no effect without WithCircuitBreaker, or if window is not positive.
+func WithCircuitBreakerWindow(window time.Duration, failureRate float64) Option {
+	return func(cfg *config) {
+		cfg.breakerWindow = window
+		cfg.breakerFailureRate = failureRate
+	}
+}
+
+// Number of buckets the rolling window of a circuitBreaker is divided into. Outcomes expire one bucket at a time.
+const breakerBuckets = 10
+
+// Outcomes of the attempts recorded during a slice of the rolling window.
+type breakerBucket struct {
+	// Start of the slice of the window.
+	start time.Time
+	// Number of attempts recorded.
+	total int
+	// Number of failed attempts among them.
+	failures int
+}
+
+// State of a circuitBreaker.
+type breakerState int
+
+const (
+	// Requests are sent normally.
+	breakerClosed breakerState = iota
+	// Requests are rejected.
+	breakerOpen
+	// A single probe request is allowed, to check whether the service recovered.
+	breakerHalfOpen
+)
+
+// Tracks the failures of the Gen-API service, and rejects requests while it looks down. A nil breaker allows every
+// request.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	// Number of failures that open the breaker.
+	threshold int
+	// How long the breaker stays open.
+	cooldown time.Duration
+	// Rolling window over which the failures are counted. Consecutive failures are counted if zero.
+	window time.Duration
+	// Share of the attempts of the window that must fail to open the breaker.
+	failureRate float64
+
+	state breakerState
+	// Number of consecutive failures so far, when not counting over a window.
+	failures int
+	// Outcomes of the attempts of the rolling window.
+	buckets [breakerBuckets]breakerBucket
+	// When the breaker was last opened.
+	openedAt time.Time
+	// Whether the probe request of the half-open state is in flight.
+	probing bool
+}
+
+// Returns an error if the request must not be sent. Every allowed request must be followed by a call to record.
+func (breaker *circuitBreaker) allow(now time.Time) error {
+	if breaker == nil {
+		return nil
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	switch breaker.state {
+	case breakerOpen:
+		if now.Sub(breaker.openedAt) < breaker.cooldown {
+			return ErrCircuitOpen
+		}
+
+		breaker.state = breakerHalfOpen
+		breaker.probing = true
+	case breakerHalfOpen:
+		if breaker.probing {
+			return ErrCircuitOpen
+		}
+
+		breaker.probing = true
+	case breakerClosed:
+	}
+
+	return nil
+}
+
+// Records the outcome of an allowed request. Outcomes that say nothing about the health of the service, like a
+// cancelled call, must be recorded with neutral set to true.
+func (breaker *circuitBreaker) record(now time.Time, res *http.Response, err error, neutral bool) {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.probing = false
+
+	if neutral {
+		return
+	}
+
+	failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+
+	if breaker.window > 0 {
+		breaker.recordInWindow(now, failed)
+		return
+	}
+
+	if !failed {
+		breaker.state = breakerClosed
+		breaker.failures = 0
+
+		return
+	}
+
+	breaker.failures++
+
+	if breaker.state == breakerHalfOpen || breaker.failures >= breaker.threshold {
+		breaker.state = breakerOpen
+		breaker.openedAt = now
+	}
+}
+
+// Implements record, when the failures are counted over a rolling window.
+func (breaker *circuitBreaker) recordInWindow(now time.Time, failed bool) {
+	if breaker.state == breakerHalfOpen {
+		if failed {
+			breaker.state = breakerOpen
+			breaker.openedAt = now
+
+			return
+		}
+
+		// The service recovered: the failures that opened the breaker are not relevant anymore.
+		breaker.state = breakerClosed
+		breaker.buckets = [breakerBuckets]breakerBucket{}
+
+		return
+	}
+
+	size := max(breaker.window/breakerBuckets, 1)
+	start := now.Truncate(size)
+
+	// Use the bucket of the current slice of the window, or else recycle the oldest one.
+	bucket := &breaker.buckets[0]
+	for i := range breaker.buckets {
+		if breaker.buckets[i].start.Equal(start) {
+			bucket = &breaker.buckets[i]
+			break
+		}
+
+		if breaker.buckets[i].start.Before(bucket.start) {
+			bucket = &breaker.buckets[i]
+		}
+	}
+
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+
+	bucket.total++
+	if failed {
+		bucket.failures++
+	}
+
+	var total, failures int
+
+	for _, bucket := range breaker.buckets {
+		if now.Sub(bucket.start) < breaker.window {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+
+	if failures >= breaker.threshold && float64(failures) >= breaker.failureRate*float64(total) {
+		breaker.state = breakerOpen
+		breaker.openedAt = now
+	}
+}
+
+// Closes the breaker, after the service was found healthy.
+func (breaker *circuitBreaker) reset() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.state = breakerClosed
+	breaker.failures = 0
+	breaker.buckets = [breakerBuckets]breakerBucket{}
+	breaker.probing = false
+}
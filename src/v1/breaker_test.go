@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	breakerSuccess = &http.Response{StatusCode: http.StatusOK}
+	breakerFailure = &http.Response{StatusCode: http.StatusServiceUnavailable}
+)
+
+// Returns the breaker configured by the given options.
+func newTestBreaker(t *testing.T, opts ...Option) *circuitBreaker {
+	t.Helper()
+
+	cfg := newConfig([]string{"http://localhost"}, opts)
+	if cfg.breaker == nil {
+		t.Fatal("expected a circuit breaker")
+	}
+
+	return cfg.breaker
+}
+
+// Records the outcome of an attempt, that must be allowed.
+func recordAttempt(t *testing.T, breaker *circuitBreaker, now time.Time, res *http.Response) {
+	t.Helper()
+
+	if err := breaker.allow(now); err != nil {
+		t.Fatalf("expected the attempt to be allowed, got %v", err)
+	}
+
+	breaker.record(now, res, nil, false)
+}
+
+func TestCircuitBreakerWindowSuccessDoesNotReset(t *testing.T) {
+	breaker := newTestBreaker(t, WithCircuitBreaker(3, time.Minute), WithCircuitBreakerWindow(time.Minute, 0.5))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Successes in between failures would reset a consecutive count.
+	for _, res := range []*http.Response{breakerFailure, breakerSuccess, breakerFailure, breakerSuccess} {
+		recordAttempt(t, breaker, now, res)
+		now = now.Add(time.Second)
+	}
+
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	if err := breaker.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to open, got %v", err)
+	}
+}
+
+func TestCircuitBreakerWindowFailureRate(t *testing.T) {
+	breaker := newTestBreaker(t, WithCircuitBreaker(2, time.Minute), WithCircuitBreakerWindow(time.Minute, 0.5))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for range 5 {
+		recordAttempt(t, breaker, now, breakerSuccess)
+	}
+
+	// 2 failures out of 7 attempts: below the failure rate.
+	recordAttempt(t, breaker, now, breakerFailure)
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	if err := breaker.allow(now); err != nil {
+		t.Fatalf("expected the breaker to stay closed under the failure rate, got %v", err)
+	}
+
+	breaker.record(now, breakerFailure, nil, false)
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	// 4 failures out of 9 attempts: still below.
+	if err := breaker.allow(now); err != nil {
+		t.Fatalf("expected the breaker to stay closed under the failure rate, got %v", err)
+	}
+
+	// 5 failures out of 10 attempts.
+	breaker.record(now, breakerFailure, nil, false)
+
+	if err := breaker.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to open at the failure rate, got %v", err)
+	}
+}
+
+func TestCircuitBreakerWindowFailuresExpire(t *testing.T) {
+	breaker := newTestBreaker(t, WithCircuitBreaker(3, time.Minute), WithCircuitBreakerWindow(time.Minute, 0))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	recordAttempt(t, breaker, now, breakerFailure)
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	// The first failures are out of the window by now.
+	now = now.Add(2 * time.Minute)
+	recordAttempt(t, breaker, now, breakerFailure)
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	if err := breaker.allow(now); err != nil {
+		t.Fatalf("expected the expired failures not to count, got %v", err)
+	}
+
+	breaker.record(now, breakerFailure, nil, false)
+
+	if err := breaker.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to open, got %v", err)
+	}
+}
+
+func TestCircuitBreakerWindowProbe(t *testing.T) {
+	breaker := newTestBreaker(t, WithCircuitBreaker(1, time.Minute), WithCircuitBreakerWindow(time.Minute, 0))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	recordAttempt(t, breaker, now, breakerFailure)
+
+	// The successful probe closes the breaker, and forgets the failures of the window.
+	now = now.Add(time.Minute)
+	recordAttempt(t, breaker, now, breakerSuccess)
+	recordAttempt(t, breaker, now, breakerSuccess)
+
+	if breaker.state != breakerClosed {
+		t.Errorf("expected the breaker to be closed, got state %d", breaker.state)
+	}
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("OpensAfterConsecutiveFailures", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(3, time.Minute))
+
+		recordAttempt(t, breaker, now, breakerFailure)
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		if breaker.state != breakerClosed {
+			t.Fatalf("expected the breaker to stay closed below the threshold, got %d", breaker.state)
+		}
+
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		if err := breaker.allow(now.Add(time.Second)); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+	})
+
+	t.Run("SuccessResetsFailures", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(2, time.Minute))
+
+		recordAttempt(t, breaker, now, breakerFailure)
+		recordAttempt(t, breaker, now, breakerSuccess)
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		if breaker.state != breakerClosed {
+			t.Errorf("expected the success to reset the failures, got state %d", breaker.state)
+		}
+	})
+
+	t.Run("NeutralOutcomesAreIgnored", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(1, time.Minute))
+
+		if err := breaker.allow(now); err != nil {
+			t.Fatalf("expected the attempt to be allowed, got %v", err)
+		}
+
+		breaker.record(now, nil, errors.New("canceled"), true)
+
+		if breaker.state != breakerClosed {
+			t.Errorf("expected a neutral outcome not to open the breaker, got state %d", breaker.state)
+		}
+	})
+
+	t.Run("HalfOpenAllowsSingleProbe", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(1, time.Minute))
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		if err := breaker.allow(now.Add(59 * time.Second)); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected ErrCircuitOpen during the cooldown, got %v", err)
+		}
+
+		later := now.Add(time.Minute)
+		if err := breaker.allow(later); err != nil {
+			t.Fatalf("expected the probe to be allowed, got %v", err)
+		}
+
+		if breaker.state != breakerHalfOpen {
+			t.Errorf("expected the breaker to be half-open, got state %d", breaker.state)
+		}
+
+		if err := breaker.allow(later); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected a second probe to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("ProbeSuccessCloses", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(1, time.Minute))
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		later := now.Add(time.Minute)
+		recordAttempt(t, breaker, later, breakerSuccess)
+
+		if breaker.state != breakerClosed {
+			t.Fatalf("expected the breaker to be closed, got state %d", breaker.state)
+		}
+
+		recordAttempt(t, breaker, later, breakerSuccess)
+		recordAttempt(t, breaker, later, breakerSuccess)
+	})
+
+	t.Run("ProbeFailureReopens", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(3, time.Minute))
+		for range 3 {
+			recordAttempt(t, breaker, now, breakerFailure)
+		}
+
+		later := now.Add(time.Minute)
+		recordAttempt(t, breaker, later, breakerFailure)
+
+		if err := breaker.allow(later.Add(59 * time.Second)); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected a new cooldown after the failed probe, got %v", err)
+		}
+
+		if err := breaker.allow(later.Add(time.Minute)); err != nil {
+			t.Errorf("expected a new probe after the cooldown, got %v", err)
+		}
+	})
+
+	t.Run("ResetCloses", func(t *testing.T) {
+		breaker := newTestBreaker(t, WithCircuitBreaker(1, time.Minute))
+		recordAttempt(t, breaker, now, breakerFailure)
+
+		breaker.reset()
+
+		if err := breaker.allow(now); err != nil {
+			t.Errorf("expected the reset breaker to allow requests, got %v", err)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cfg := newConfig([]string{"http://localhost"}, []Option{WithCircuitBreaker(0, time.Minute)})
+		if cfg.breaker != nil {
+			t.Fatal("expected no circuit breaker")
+		}
+
+		for range 10 {
+			if err := cfg.breaker.allow(now); err != nil {
+				t.Fatalf("expected a nil breaker to allow requests, got %v", err)
+			}
+
+			cfg.breaker.record(now, breakerFailure, nil, false)
+		}
+	})
+}
+
+func TestCircuitBreakerRejectsCalls(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{Status: http.StatusInternalServerError})
+
+	clock := testutil.NewFakeClock(time.Now())
+	api := NewCreateLogLineAPI(server.URL, WithCircuitBreaker(2, time.Minute), WithClock(clock))
+
+	for range 2 {
+		if _, _, err := api.Call(context.Background(), "an instruction", nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("expected the breaker to be closed")
+		}
+	}
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if got := countRequests(server, http.MethodPut, "/api/v1/log-lines"); got != 2 {
+		t.Errorf("expected the rejected call to send nothing, got %d requests", got)
+	}
+
+	// The service recovered: the probe sent after the cooldown closes the breaker.
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+		Status: http.StatusOK,
+		Body:   map[string]string{"logLine": "a fake log line"},
+	})
+	clock.Advance(time.Minute)
+
+	for range 2 {
+		if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+			t.Errorf("expected the breaker to close, got %v", err)
+		}
+	}
+}
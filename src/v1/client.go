@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// LogLinesAPI groups the APIs dealing with log lines.
+type LogLinesAPI struct {
+	// Create generates new log lines.
+	Create CreateLogLineAPI
+	// Validate checks whether a text is a valid log line.
+	Validate ValidateLogLineAPI
+	// Analyze describes existing log lines.
+	Analyze AnalyzeLogLineAPI
+}
+
+// Client gives access to every API of the Gen-API service.
+//
+// All the APIs of a client share the same configuration, including the underlying HTTP client, so connections are
+// reused across them.
+type Client struct {
+	// LogLines groups the APIs dealing with log lines.
+	LogLines LogLinesAPI
+	// Ping checks the availability of the Gen-API service.
+	Ping PingAPI
+
+	// Configuration shared by the APIs.
+	cfg *config
+}
+
+// NewClient returns a new Client, with every API configured with the given options.
+//
+// The endpoint is the root URL for accessing the Gen-API service.
+func NewClient(endpoint string, opts ...Option) *Client {
+	return newClient(newConfig([]string{endpoint}, opts))
+}
+
+// NewClientWithEndpoints returns a new Client, that spreads its requests across multiple replicas of the Gen-API
+// service, in a round-robin fashion. When retries are enabled, a failed attempt is retried on the next endpoint.
+//
+// Each endpoint must be an absolute URL: an error is returned otherwise, or if no endpoint is given.
+func NewClientWithEndpoints(endpoints []string, opts ...Option) (*Client, error) {
+	if err := validateEndpoints(endpoints); err != nil {
+		return nil, err
+	}
+
+	return newClient(newConfig(slices.Clone(endpoints), opts)), nil
+}
+
+// Wires the APIs of a Client to the shared configuration.
+func newClient(cfg *config) *Client {
+	return &Client{
+		LogLines: LogLinesAPI{
+			Create:   &createLogLineAPI{cfg: cfg},
+			Validate: &validateLogLineAPI{cfg: cfg},
+			Analyze:  &analyzeLogLineAPI{cfg: cfg},
+		},
+		Ping: &pingAPI{cfg: cfg},
+		cfg:  cfg,
+	}
+}
+
+// Do sends a request to an arbitrary route of the Gen-API service, for routes that are not supported by this package
+// yet. It goes through the same plumbing as the other APIs: authentication, headers, retries, timeout, and so on.
+//
+// The subPath is relative to the endpoint of the client. The body, if not nil, is sent as JSON. The JSON response is
+// decoded into out, unless out is nil. Do returns an error if the response status differs from wantStatus.
+func (client *Client) Do(
+	ctx context.Context, method, subPath string, body any, out any, wantStatus int,
+) (int, error) {
+	ctx, span := client.cfg.startSpan(ctx, "gen-api.Do")
+
+	status, err := client.cfg.doJSON(ctx, method, subPath, body, out, wantStatus)
+	span.end(status, err)
+
+	return status, err
+}
+
+// CreateValidatedMeta describes how CreateValidated obtained its log line.
+type CreateValidatedMeta struct {
+	// Number of log lines generated, including the returned one.
+	Attempts int
+}
+
+// CreateValidated generates a log line, and checks that it is valid before returning it. Invalid log lines are
+// discarded, and a new one is generated, up to maxAttempts log lines in total.
+//
+// Any error other than ErrInvalidLogLine, from either the create or the validate API, is returned right away, along
+// with the status of the failed call. If every generated log line is invalid, the error of the last validation is
+// returned.
+func (client *Client) CreateValidated(
+	ctx context.Context, instruction string, remix []string, maxAttempts int,
+) (string, CreateValidatedMeta, int, error) {
+	if maxAttempts < 1 {
+		return "", CreateValidatedMeta{}, 0, fmt.Errorf(
+			"%w: at least 1 attempt must be allowed, got %d", ErrInvalidArgument, maxAttempts,
+		)
+	}
+
+	var meta CreateValidatedMeta
+
+	for {
+		meta.Attempts++
+
+		logLine, status, err := client.LogLines.Create.Call(ctx, instruction, remix)
+		if err != nil {
+			return "", meta, status, err
+		}
+
+		status, err = client.LogLines.Validate.Call(ctx, logLine)
+		if err == nil {
+			return logLine, meta, status, nil
+		}
+
+		if !errors.Is(err, ErrInvalidLogLine) || meta.Attempts >= maxAttempts {
+			return "", meta, status, err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time, and waits, on behalf of the client. It is used to compute the delays between retries, the
+// cooldowns of the circuit breaker and endpoint failover, the expiration of cached results and the latency of
+// requests. Waits that can be interrupted by a context rely on After, or on AfterContext if the clock implements
+// ContextClock.
+//
+// Replace it with WithClock to make these behaviors deterministic in tests. The deadlines of the contexts, including
+// the one set by WithTimeout, still follow the real time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep pauses the current goroutine for the given duration.
+	Sleep(d time.Duration)
+	// After waits for the given duration, then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Implements the Clock interface, with the functions of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ContextClock is a Clock whose waits can be given up on. When the clock of the client implements it, the waits
+// interrupted by a context are released right away, rather than once their duration is over.
+type ContextClock interface {
+	Clock
+	// AfterContext works like After, but stops waiting once the context is done. The channel then never receives.
+	AfterContext(ctx context.Context, d time.Duration) <-chan time.Time
+}
+
+// WithClock sets the clock used by the client. It defaults to the real time.
+func WithClock(clock Clock) Option {
+	return func(cfg *config) {
+		cfg.clock = clock
+	}
+}
+
+// Waits for the given duration, or until the context is done.
+func (cfg *config) sleep(ctx context.Context, delay time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-cfg.after(ctx, delay):
+		return nil
+	}
+}
+
+// Returns a channel that receives once the given duration is over. The wait is given up on once the context is done,
+// if the clock supports it.
+func (cfg *config) after(ctx context.Context, delay time.Duration) <-chan time.Time {
+	if clock, ok := cfg.clock.(ContextClock); ok {
+		return clock.AfterContext(ctx, delay)
+	}
+
+	return cfg.clock.After(delay)
+}
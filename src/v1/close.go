@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by the calls made after the client was closed.
+var ErrClientClosed = errors.New("client is closed")
+
+// Tracks the calls in flight, so the client can wait for them before closing.
+type callTracker struct {
+	mu sync.RWMutex
+
+	// Whether the client was closed. No new call is accepted once set.
+	closed bool
+	// Counts the calls in flight.
+	wg sync.WaitGroup
+}
+
+// Registers a new call. The returned function must be called once the call is done, including the reading of its
+// response. An ErrClientClosed is returned if the client was closed.
+func (tracker *callTracker) start() (func(), error) {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+
+	if tracker.closed {
+		return nil, ErrClientClosed
+	}
+
+	tracker.wg.Add(1)
+
+	return tracker.wg.Done, nil
+}
+
+// Close stops the client: the calls made afterward fail with ErrClientClosed. It then waits for the calls in flight
+// to complete, and closes the idle connections of the HTTP client.
+//
+// If the context is done before every call completes, Close stops waiting and returns the error of the context. The
+// remaining calls are not interrupted. Closing a client multiple times is harmless.
+func (client *Client) Close(ctx context.Context) error {
+	client.cfg.calls.mu.Lock()
+	client.cfg.calls.closed = true
+	client.cfg.calls.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		client.cfg.calls.wg.Wait()
+		close(drained)
+	}()
+
+	var err error
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	client.cfg.httpClient.CloseIdleConnections()
+
+	return err
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Codec encodes the bodies of the requests sent to the Gen-API service, and decodes the bodies of its responses.
+//
+// Implementations must follow the semantics of encoding/json, including the handling of struct tags.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// The default Codec, based on encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// WithCodec sets the Codec used to encode requests and decode responses, as a replacement for encoding/json. It is
+// useful to plug in a faster implementation, like json-iterator.
+func WithCodec(codec Codec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
+
+// Decodes the body of the response into out, using the configured Codec.
+func (cfg *config) decode(res *http.Response, out any) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return cfg.codec.Unmarshal(data, out)
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// A Codec that marks the values it encodes, and counts them.
+type markingCodec struct {
+	jsonCodec
+	encoded atomic.Int32
+}
+
+func (codec *markingCodec) Marshal(v any) ([]byte, error) {
+	codec.encoded.Add(1)
+
+	data, err := codec.jsonCodec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Still valid JSON, so the server accepts it.
+	return append([]byte(" "), data...), nil
+}
+
+func TestWithCodec(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	codec := &markingCodec{}
+	api := NewCreateLogLineAPI(server.URL, WithCodec(codec))
+
+	logLine, _, err := api.Call(context.Background(), "an instruction", nil)
+	if err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	if logLine != "a fake log line" {
+		t.Errorf("expected the decoded log line, got %q", logLine)
+	}
+
+	if got := codec.encoded.Load(); got != 1 {
+		t.Errorf("expected the codec to encode the request, got %d values", got)
+	}
+
+	if body := server.Requests()[0].Body; !strings.HasPrefix(body, " ") {
+		t.Errorf("expected the body encoded by the codec, got %q", body)
+	}
+}
+
+// A create request, with a few remix entries.
+var benchmarkCreateRequest = createLogLineRequest{
+	Instruction: "A scientist leads humanity's first colony on a distant exoplanet.",
+	Remix:       []string{"cyberpunk", "space opera", "hard science fiction", "rogue AI"},
+	N:           1,
+	Temperature: 0.7,
+}
+
+func BenchmarkCodecMarshal(b *testing.B) {
+	codec := jsonCodec{}
+
+	b.ReportAllocs()
+
+	for range b.N {
+		if _, err := codec.Marshal(benchmarkCreateRequest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	cfg := newConfig([]string{"http://localhost"}, nil)
+	body := []byte(
+		`{"logLine":"` + strings.Repeat("a fake log line ", 64) + `","model":"fake","usage":{"promptTokens":12}}`,
+	)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+
+	for range b.N {
+		res := &http.Response{Body: io.NopCloser(bytes.NewReader(body))}
+
+		var out createLogLineResponse
+		if err := cfg.decode(res, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
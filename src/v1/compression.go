@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// WithRequestCompression gzip-compresses the bodies of at least minBytes bytes, before sending them to the Gen-API
+// service. Smaller bodies are sent as-is, as compressing them would cost more than it saves.
+//
+// If the server rejects a compressed body with a 415 status, the request is sent again uncompressed, and the client
+// stops compressing the bodies of the next requests.
+func WithRequestCompression(minBytes int) Option {
+	return func(cfg *config) {
+		cfg.compression = &requestCompression{minBytes: minBytes}
+	}
+}
+
+// Compresses the bodies of the requests. A nil requestCompression never compresses anything.
+type requestCompression struct {
+	// Bodies smaller than this are not compressed.
+	minBytes int
+	// Whether the server rejected a compressed body.
+	rejected atomic.Bool
+}
+
+// Returns the gzip-compressed body, or nil if the body should be sent as-is.
+func (compression *requestCompression) compress(body []byte) []byte {
+	if compression == nil || body == nil || len(body) < compression.minBytes || compression.rejected.Load() {
+		return nil
+	}
+
+	var compressed bytes.Buffer
+
+	// Writing to a buffer never fails.
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write(body)
+	_ = writer.Close()
+
+	return compressed.Bytes()
+}
+
+// WithAcceptGzip asks the Gen-API service to gzip-compress its responses, using the Accept-Encoding header.
+//
+// Compressed responses are decompressed transparently, whether this option is used or not. The limit of
+// WithMaxResponseBytes applies to the decompressed body.
+func WithAcceptGzip() Option {
+	return func(cfg *config) {
+		cfg.acceptGzip = true
+	}
+}
+
+// Replaces the body of a gzip-compressed response with its decompressed content.
+func decompressResponse(res *http.Response) {
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+
+	res.Body = &gzipBody{body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// Decompresses a gzip body. The gzip header is only read on the first call to Read, so responses that turn out to
+// have no body can still be closed without error.
+type gzipBody struct {
+	// The compressed body.
+	body io.ReadCloser
+	// Decompresses the body. Created on first read.
+	reader *gzip.Reader
+	// Error encountered while creating the reader, returned by every read.
+	err error
+}
+
+func (body *gzipBody) Read(p []byte) (int, error) {
+	if body.reader == nil && body.err == nil {
+		body.reader, body.err = gzip.NewReader(body.body)
+	}
+
+	if body.err != nil {
+		return 0, body.err
+	}
+
+	return body.reader.Read(p)
+}
+
+func (body *gzipBody) Close() error {
+	var err error
+	if body.reader != nil {
+		err = body.reader.Close()
+	}
+
+	return errors.Join(err, body.body.Close())
+}
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidConfig is wrapped by the errors returned by Config.Validate.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is a declarative alternative to the options of this package. Zero fields keep the defaults of the client.
+type Config struct {
+	// The root URL for accessing the Gen-API service. Required.
+	Endpoint string
+	// See WithHTTPClient.
+	HTTPClient *http.Client
+	// See WithTimeout.
+	Timeout time.Duration
+	// See WithRetry.
+	Retry RetryConfig
+	// Authentication of the requests.
+	Auth AuthConfig
+	// See WithUserAgent.
+	UserAgent string
+}
+
+// RetryConfig configures the retries of a Config. Calls are not retried if MaxAttempts is lower than 2.
+type RetryConfig struct {
+	// Maximum number of attempts for a single call, including the first one.
+	MaxAttempts int
+	// Delay before the first retry.
+	Base time.Duration
+}
+
+// AuthConfig configures the authentication of a Config. At most one of BearerToken and TokenSource can be set, and
+// they can be combined with an API key.
+type AuthConfig struct {
+	// See WithBearerToken.
+	BearerToken string
+	// See WithTokenSource.
+	TokenSource TokenSource
+	// See WithAPIKey. The header defaults to X-API-Key when empty.
+	APIKeyHeader string
+	APIKey       string
+}
+
+// Validate checks that the configuration can be used to build a client. Every issue found is reported, and wraps
+// ErrInvalidConfig.
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Endpoint == "" {
+		errs = append(errs, ErrNoEndpoint)
+	} else if err := validateEndpoints([]string{cfg.Endpoint}); err != nil {
+		errs = append(errs, err)
+	}
+
+	if cfg.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("negative timeout: %s", cfg.Timeout))
+	}
+
+	if cfg.Retry.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("negative number of retry attempts: %d", cfg.Retry.MaxAttempts))
+	}
+
+	if cfg.Retry.Base < 0 {
+		errs = append(errs, fmt.Errorf("negative retry delay: %s", cfg.Retry.Base))
+	}
+
+	if cfg.Auth.BearerToken != "" && cfg.Auth.TokenSource != nil {
+		errs = append(errs, errors.New("both a bearer token and a token source are set"))
+	}
+
+	if cfg.Auth.APIKeyHeader != "" && cfg.Auth.APIKey == "" {
+		errs = append(errs, errors.New("an API key header is set without an API key"))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// Options returns the options equivalent to the configuration.
+func (cfg *Config) Options() []Option {
+	var opts []Option
+
+	if cfg.HTTPClient != nil {
+		opts = append(opts, WithHTTPClient(cfg.HTTPClient))
+	}
+
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(cfg.Timeout))
+	}
+
+	if cfg.Retry.MaxAttempts > 1 {
+		opts = append(opts, WithRetry(cfg.Retry.MaxAttempts, cfg.Retry.Base))
+	}
+
+	if cfg.Auth.BearerToken != "" {
+		opts = append(opts, WithBearerToken(cfg.Auth.BearerToken))
+	}
+
+	if cfg.Auth.TokenSource != nil {
+		opts = append(opts, WithTokenSource(cfg.Auth.TokenSource))
+	}
+
+	if cfg.Auth.APIKey != "" {
+		opts = append(opts, WithAPIKey(cfg.Auth.APIKeyHeader, cfg.Auth.APIKey))
+	}
+
+	if cfg.UserAgent != "" {
+		opts = append(opts, WithUserAgent(cfg.UserAgent))
+	}
+
+	return opts
+}
+
+// NewClientFromConfig returns a new Client built from the configuration, once validated. The given options are
+// applied after the ones of the configuration, for the settings the configuration does not cover.
+func NewClientFromConfig(cfg Config, opts ...Option) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return newClient(newConfig([]string{cfg.Endpoint}, append(cfg.Options(), opts...))), nil
+}
@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      Config
+		expected error
+	}{
+		{name: "Valid", cfg: Config{Endpoint: "http://localhost", Timeout: time.Second}, expected: nil},
+		{name: "NoEndpoint", cfg: Config{}, expected: ErrNoEndpoint},
+		{
+			name:     "NegativeTimeout",
+			cfg:      Config{Endpoint: "http://localhost", Timeout: -time.Second},
+			expected: ErrInvalidConfig,
+		},
+		{
+			name: "BearerTokenAndTokenSource",
+			cfg: Config{
+				Endpoint: "http://localhost",
+				Auth: AuthConfig{
+					BearerToken: "token",
+					TokenSource: func(context.Context) (string, error) { return "token", nil },
+				},
+			},
+			expected: ErrInvalidConfig,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.cfg.Validate()
+			if testCase.expected == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, testCase.expected) || !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("expected %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
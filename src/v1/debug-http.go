@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// WithDebugHTTP writes every request sent to the Gen-API service, and every response received, to the given writer,
+// as they appear on the wire. This is a development aid, that slows down the client.
+//
+// Bodies are redacted, unless WithLogBodies is also used. The bodies of event streams are never dumped, so streaming
+// calls are not delayed. The credentials of the client, sent in the Authorization header and the API key header (see
+// WithAPIKey), are always redacted.
+func WithDebugHTTP(w io.Writer) Option {
+	return func(cfg *config) {
+		cfg.debugHTTP = &httpDumper{w: w}
+	}
+}
+
+// Dumps the requests and responses to a writer. A nil httpDumper dumps nothing.
+type httpDumper struct {
+	// Prevents the dumps of concurrent requests from interleaving.
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Writes a dump, or the error that prevented it.
+func (dumper *httpDumper) write(kind string, dump []byte, err error) {
+	dumper.mu.Lock()
+	defer dumper.mu.Unlock()
+
+	if err != nil {
+		_, _ = fmt.Fprintf(dumper.w, "--- gen-api %s: failed to dump: %v\n", kind, err)
+		return
+	}
+
+	_, _ = fmt.Fprintf(dumper.w, "--- gen-api %s\n%s\n", kind, dump)
+}
+
+// Replaces the value of the credentials in dumps.
+const redactedValue = "[REDACTED]"
+
+// Dumps a request, about to be sent. The body of the request is preserved.
+func (cfg *config) dumpRequest(req *http.Request) {
+	if cfg.debugHTTP == nil {
+		return
+	}
+
+	// Redact a clone, so the credentials are still sent. The clone shares the body of the request.
+	clone := req.Clone(req.Context())
+	for _, header := range []string{"Authorization", cfg.apiKeyHeader} {
+		if header != "" && clone.Header.Get(header) != "" {
+			clone.Header.Set(header, redactedValue)
+		}
+	}
+
+	dump, err := httputil.DumpRequestOut(clone, cfg.logBodies)
+	// Dumping the body consumes it, and replaces it with a copy on the clone only.
+	req.Body = clone.Body
+	cfg.debugHTTP.write("request", dump, err)
+}
+
+// Dumps a response, as read by the client: decompressed, and limited in size. When dumped, the body is replaced with
+// a copy, so it can still be read.
+func (cfg *config) dumpResponse(res *http.Response) {
+	if cfg.debugHTTP == nil || res == nil {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	withBody := cfg.logBodies && mediaType != "text/event-stream"
+
+	dump, err := httputil.DumpResponse(res, withBody)
+	if err != nil && withBody {
+		// The body was partially consumed: reading it again must fail the same way, rather than return what is left.
+		res.Body = &failedBody{ReadCloser: res.Body, err: err}
+	}
+
+	cfg.debugHTTP.write("response", dump, err)
+}
+
+// A body that failed to be read.
+type failedBody struct {
+	io.ReadCloser
+	// The error returned by every read.
+	err error
+}
+
+func (body *failedBody) Read([]byte) (int, error) {
+	return 0, body.err
+}
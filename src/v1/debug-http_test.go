@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHTTPRedactsCredentials(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	dump := new(bytes.Buffer)
+	api := NewPingAPI(server.URL, WithDebugHTTP(dump), WithBearerToken("tok"), WithAPIKey("", "secret-key"))
+
+	if _, err := api.Call(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	for _, secret := range []string{"tok", "secret-key"} {
+		if strings.Contains(dump.String(), secret) {
+			t.Errorf("expected the dump not to contain %q:\n%s", secret, dump)
+		}
+	}
+
+	if !strings.Contains(dump.String(), redactedValue) {
+		t.Errorf("expected the dump to contain %q:\n%s", redactedValue, dump)
+	}
+
+	// Only the dump is redacted.
+	header := recorder.last(t)
+
+	if got := header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("expected the bearer token to be sent, got %q", got)
+	}
+
+	if got := header.Get(defaultAPIKeyHeader); got != "secret-key" {
+		t.Errorf("expected the API key to be sent, got %q", got)
+	}
+}
+
+func TestDebugHTTPPreservesRequestBody(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	dump := new(bytes.Buffer)
+	api := NewCreateLogLineAPI(server.URL, WithDebugHTTP(dump), WithLogBodies(true))
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+
+	if !strings.Contains(requests[0].Body, "an instruction") {
+		t.Errorf("expected the server to receive the full body, got %q", requests[0].Body)
+	}
+
+	if !strings.Contains(dump.String(), "an instruction") {
+		t.Errorf("expected the dump to contain the request body:\n%s", dump)
+	}
+
+	if !strings.Contains(dump.String(), "a fake log line") {
+		t.Errorf("expected the dump to contain the response body:\n%s", dump)
+	}
+}
+
+func TestDebugHTTPRespectsMaxResponseBytes(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	large := strings.Repeat("x", 1024)
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+		Body: map[string]string{"logLine": large},
+	})
+
+	dump := new(bytes.Buffer)
+	api := NewCreateLogLineAPI(server.URL, WithDebugHTTP(dump), WithLogBodies(true), WithMaxResponseBytes(64))
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	if strings.Contains(dump.String(), large) {
+		t.Error("expected the dump not to read past the limit")
+	}
+}
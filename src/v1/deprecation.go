@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// WithDeprecationCallback calls the given function whenever a response of the Gen-API service carries a Deprecation
+// or Sunset header, announcing that a route is going to change or disappear. The route is the method and path of the
+// request, like "PUT /api/v1/log-lines", and the message describes the headers received.
+//
+// Without a callback, a warning is logged the first time each route is reported as deprecated.
+func WithDeprecationCallback(callback func(endpoint, message string)) Option {
+	return func(cfg *config) {
+		cfg.onDeprecation = callback
+	}
+}
+
+// Reports the deprecation headers of a response, if any.
+func (cfg *config) checkDeprecation(ctx context.Context, req *http.Request, res *http.Response) {
+	if res == nil {
+		return
+	}
+
+	var details []string
+	if deprecation := res.Header.Get("Deprecation"); deprecation != "" {
+		details = append(details, "deprecated since "+deprecation)
+	}
+	if sunset := res.Header.Get("Sunset"); sunset != "" {
+		details = append(details, "removed on "+sunset)
+	}
+
+	if len(details) == 0 {
+		return
+	}
+
+	route := req.Method + " " + req.URL.Path
+	message := strings.Join(details, ", ")
+
+	if cfg.onDeprecation != nil {
+		cfg.onDeprecation(route, message)
+		return
+	}
+
+	if _, warned := cfg.deprecationWarnings.LoadOrStore(route, struct{}{}); warned {
+		return
+	}
+
+	cfg.warnLogger().WarnContext(
+		ctx, "gen-api route is deprecated", slog.String("route", route), slog.String("details", message),
+	)
+}
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Records the notices received by a deprecation callback.
+type deprecationNotices struct {
+	mu sync.Mutex
+
+	// Notices received so far, as "route: message".
+	notices []string
+}
+
+func (notices *deprecationNotices) callback(route, message string) {
+	notices.mu.Lock()
+	defer notices.mu.Unlock()
+
+	notices.notices = append(notices.notices, route+": "+message)
+}
+
+func TestDeprecationCallback(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   http.Header
+		expected []string
+	}{
+		{
+			name:     "Deprecation",
+			header:   http.Header{"Deprecation": {"@1688169599"}},
+			expected: []string{"PUT /api/v1/log-lines: deprecated since @1688169599"},
+		},
+		{
+			name:   "DeprecationAndSunset",
+			header: http.Header{"Deprecation": {"true"}, "Sunset": {"Wed, 11 Nov 2026 11:11:11 GMT"}},
+			expected: []string{
+				"PUT /api/v1/log-lines: deprecated since true, removed on Wed, 11 Nov 2026 11:11:11 GMT",
+			},
+		},
+		{name: "NotDeprecated", header: nil, expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+				Status: http.StatusOK,
+				Header: testCase.header,
+				Body:   map[string]string{"logLine": "a fake log line"},
+			})
+
+			notices := &deprecationNotices{}
+			api := NewCreateLogLineAPI(server.URL, WithDeprecationCallback(notices.callback))
+
+			if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			if len(notices.notices) != len(testCase.expected) {
+				t.Fatalf("expected %d notices, got %q", len(testCase.expected), notices.notices)
+			}
+
+			for i, expected := range testCase.expected {
+				if notices.notices[i] != expected {
+					t.Errorf("expected notice %q, got %q", expected, notices.notices[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecationWarningIsLoggedOnce(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+		Status: http.StatusOK,
+		Header: http.Header{"Deprecation": {"true"}},
+		Body:   map[string]string{"logLine": "a fake log line"},
+	})
+
+	var logs bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	api := NewCreateLogLineAPI(server.URL, WithLogger(logger))
+
+	for range 3 {
+		if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+			t.Fatalf("failed to create a log line: %v", err)
+		}
+	}
+
+	if got := strings.Count(logs.String(), "gen-api route is deprecated"); got != 1 {
+		t.Errorf("expected a single warning, got %d:\n%s", got, logs.String())
+	}
+}
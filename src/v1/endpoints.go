@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNoEndpoint is returned when a client is built without any endpoint.
+var ErrNoEndpoint = errors.New("no endpoint provided")
+
+// Checks that every endpoint is an absolute URL.
+func validateEndpoints(endpoints []string) error {
+	if len(endpoints) == 0 {
+		return ErrNoEndpoint
+	}
+
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid endpoint %q: must be an absolute URL", endpoint)
+		}
+	}
+
+	return nil
+}
+
+// Key of the endpoint override in a context.
+type endpointOverrideKey struct{}
+
+// WithEndpointOverride sends the calls made with this context to the given endpoint, in place of the endpoints of the
+// client. This lets a single client route each call to the service of a different tenant.
+//
+// The endpoint must be an absolute URL, or the calls fail.
+func WithEndpointOverride(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointOverrideKey{}, endpoint)
+}
+
+// Returns the endpoint to send the next request of a call to: the override of the context, if any, or the next
+// endpoint of the configuration.
+func (cfg *config) endpointFor(ctx context.Context) (string, error) {
+	override, ok := ctx.Value(endpointOverrideKey{}).(string)
+	if !ok || override == "" {
+		return cfg.pickEndpoint(), nil
+	}
+
+	if err := validateEndpoints([]string{override}); err != nil {
+		return "", err
+	}
+
+	return override, nil
+}
+
+// Returns the endpoint a request is sent to, without its path.
+func endpointOf(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host
+}
+
+// WithEndpointFailover skips the endpoints that look down, for the cooldown duration. An endpoint is marked as down
+// after a request fails to reach it, or a ping to it fails. A successful ping marks it as healthy again, before the end
+// of the cooldown: use Client.MonitorEndpoints to ping every endpoint in the background.
+//
+// When all the endpoints are down, requests are sent anyway, in a round-robin fashion.
+func WithEndpointFailover(cooldown time.Duration) Option {
+	return func(cfg *config) {
+		cfg.failover = &endpointHealth{cooldown: cooldown, downUntil: make(map[string]time.Time)}
+	}
+}
+
+// Tracks the endpoints that look down. A nil endpointHealth considers every endpoint healthy.
+type endpointHealth struct {
+	mu sync.Mutex
+
+	// How long an endpoint is skipped after a failure.
+	cooldown time.Duration
+	// Endpoints marked as down, and when they can be used again.
+	downUntil map[string]time.Time
+}
+
+// Marks the endpoint as down, for the cooldown duration.
+func (health *endpointHealth) markDown(endpoint string, now time.Time) {
+	if health == nil {
+		return
+	}
+
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	health.downUntil[endpoint] = now.Add(health.cooldown)
+}
+
+// Marks the endpoint as healthy.
+func (health *endpointHealth) markUp(endpoint string) {
+	if health == nil {
+		return
+	}
+
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	delete(health.downUntil, endpoint)
+}
+
+// Reports whether the endpoint can be used.
+func (health *endpointHealth) healthy(endpoint string, now time.Time) bool {
+	if health == nil {
+		return true
+	}
+
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	downUntil, ok := health.downUntil[endpoint]
+
+	return !ok || !now.Before(downUntil)
+}
+
+// Returns the endpoint to send the next request to. Requests are spread across the healthy endpoints in a
+// round-robin fashion, so each retry of a call goes to a different endpoint, unless latency-aware routing picks the
+// fastest one.
+func (cfg *config) pickEndpoint() string {
+	if len(cfg.endpoints) == 1 {
+		return cfg.endpoints[0]
+	}
+
+	now := cfg.clock.Now()
+
+	cfg.startProbes()
+
+	healthy := func(endpoint string) bool {
+		return cfg.failover.healthy(endpoint, now)
+	}
+
+	if endpoint, ok := cfg.latencyRouting.fastest(cfg.endpoints, healthy); ok {
+		return endpoint
+	}
+	start := cfg.nextEndpoint.Add(1) - 1
+
+	for i := range uint64(len(cfg.endpoints)) {
+		endpoint := cfg.endpoints[(start+i)%uint64(len(cfg.endpoints))]
+		if healthy(endpoint) {
+			return endpoint
+		}
+	}
+
+	// Every endpoint is down: one of them might have recovered already.
+	return cfg.endpoints[start%uint64(len(cfg.endpoints))]
+}
+
+// HealthyEndpoints returns the endpoints of the client that are not currently skipped by the failover (see
+// WithEndpointFailover). Every endpoint is returned when failover is disabled.
+func (client *Client) HealthyEndpoints() []string {
+	now := client.cfg.clock.Now()
+	healthy := make([]string, 0, len(client.cfg.endpoints))
+
+	for _, endpoint := range client.cfg.endpoints {
+		if client.cfg.failover.healthy(endpoint, now) {
+			healthy = append(healthy, endpoint)
+		}
+	}
+
+	return healthy
+}
+
+// MonitorEndpoints pings every endpoint of the client, right away and then every interval, until the context is
+// done. With failover enabled, this detects endpoints that went down, and restores the ones that recovered without
+// waiting for the end of their cooldown. The waits between pings follow the clock of the client (see WithClock).
+//
+// The interval must be positive, or an error wrapping ErrInvalidArgument is returned, and nothing is monitored.
+func (client *Client) MonitorEndpoints(ctx context.Context, interval time.Duration) error {
+	// Without a positive interval, the endpoints would be pinged in a tight loop.
+	if interval <= 0 {
+		return fmt.Errorf("%w: the interval must be positive, got %s", ErrInvalidArgument, interval)
+	}
+
+	api := &pingAPI{cfg: client.cfg}
+
+	go func() {
+		for {
+			for _, endpoint := range client.cfg.endpoints {
+				_, _, _ = api.pingEndpoint(ctx, endpoint)
+			}
+
+			if err := client.cfg.sleep(ctx, interval); err != nil {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
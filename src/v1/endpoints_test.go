@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMonitorEndpointsFollowsClock(t *testing.T) {
+	first, second := testutil.NewFakeServer(), testutil.NewFakeServer()
+	defer first.Close()
+	defer second.Close()
+
+	clock := testutil.NewFakeClock(time.Now())
+
+	client, err := NewClientWithEndpoints(
+		[]string{first.URL, second.URL}, WithClock(clock), WithEndpointFailover(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// An endpoint that failed is skipped until the end of its cooldown, unless a ping finds it healthy again.
+	client.cfg.failover.markDown(first.URL, clock.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := client.MonitorEndpoints(ctx, time.Minute); err != nil {
+		t.Fatalf("failed to monitor the endpoints: %v", err)
+	}
+
+	pings := func() int {
+		return countRequests(first, http.MethodGet, "/ping") + countRequests(second, http.MethodGet, "/ping")
+	}
+
+	eventually(t, func() bool { return pings() == 2 && clock.Waiters() == 1 }, "the endpoints were not pinged")
+
+	if got := len(client.HealthyEndpoints()); got != 2 {
+		t.Errorf("expected the endpoint to be restored by the ping, got %d healthy endpoints", got)
+	}
+
+	// The next pings only happen once the clock moves.
+	clock.Advance(time.Minute)
+	eventually(t, func() bool { return pings() == 4 && clock.Waiters() == 1 }, "the endpoints were not pinged again")
+
+	// Once the context is done, the monitoring stops.
+	cancel()
+	eventually(t, func() bool { return clock.Waiters() == 0 }, "the monitoring did not stop")
+}
+
+func TestMonitorEndpointsInvalidInterval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "Zero", interval: 0},
+		{name: "Negative", interval: -time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			client := NewClient(server.URL)
+
+			if err := client.MonitorEndpoints(context.Background(), testCase.interval); !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("expected ErrInvalidArgument, got %v", err)
+			}
+
+			if got := countRequests(server, http.MethodGet, "/ping"); got != 0 {
+				t.Errorf("expected no ping, got %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by NewClientFromEnv.
+const (
+	envEndpoint   = "GEN_API_ENDPOINT"
+	envToken      = "GEN_API_TOKEN"
+	envTimeout    = "GEN_API_TIMEOUT"
+	envMaxRetries = "GEN_API_MAX_RETRIES"
+)
+
+// Delay before the first retry, for the retries configured by NewClientFromEnv.
+const envRetryBase = 100 * time.Millisecond
+
+// NewClientFromEnv returns a new Client, configured from the environment:
+//   - GEN_API_ENDPOINT (required) is the root URL of the Gen-API service. Multiple endpoints can be given, separated
+//     by commas (see NewClientWithEndpoints).
+//   - GEN_API_TOKEN authenticates the requests with a bearer token (see WithBearerToken).
+//   - GEN_API_TIMEOUT bounds the duration of each call, written as a Go duration like "5s" (see WithTimeout).
+//   - GEN_API_MAX_RETRIES is the number of times a failed call is retried, with a delay starting at 100ms (see
+//     WithRetry).
+//
+// Unset optional variables keep the defaults of the client. The given options are applied after the ones read from
+// the environment, so they take precedence. An error is returned if an endpoint is missing, or a variable is invalid.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	var endpoints []string
+	for _, endpoint := range strings.Split(os.Getenv(envEndpoint), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("%s is not set: %w", envEndpoint, ErrNoEndpoint)
+	}
+
+	var envOpts []Option
+
+	if token := os.Getenv(envToken); token != "" {
+		envOpts = append(envOpts, WithBearerToken(token))
+	}
+
+	if value := os.Getenv(envTimeout); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envTimeout, err)
+		}
+
+		envOpts = append(envOpts, WithTimeout(timeout))
+	}
+
+	if value := os.Getenv(envMaxRetries); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return nil, fmt.Errorf("invalid %s: %q is not a non-negative integer", envMaxRetries, value)
+		}
+
+		envOpts = append(envOpts, WithRetry(retries+1, envRetryBase))
+	}
+
+	return NewClientWithEndpoints(endpoints, append(envOpts, opts...)...)
+}
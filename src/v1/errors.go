@@ -0,0 +1,250 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when the Gen-API service answers with an unexpected status. It wraps the error of
+// gatewayutils.EnsureStatus, joined with the error described by the body of the response.
+//
+// Every method of the package returns a *StatusError for unexpected statuses, possibly wrapped: use errors.As to
+// retrieve it, or StatusCodeOf to read its status.
+type StatusError struct {
+	// The status of the response.
+	Status int
+	Err    error
+	// ID of the request that got the response (see WithRequestID): the one sent back by the Gen-API service, if any,
+	// or else the one sent by the client. It correlates the failed call with the logs of the service, even when the
+	// ID was generated by the client.
+	RequestID string
+
+	// Whether the status is retried by the client, according to its retry policy.
+	retryable bool
+}
+
+func (err *StatusError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *StatusError) Unwrap() error {
+	return err.Err
+}
+
+// StatusCode returns the status of the response.
+func (err *StatusError) StatusCode() int {
+	return err.Status
+}
+
+// StatusCodeOf returns the status of the response that caused the error, if the error wraps a *StatusError.
+func StatusCodeOf(err error) (int, bool) {
+	if statusErr := new(StatusError); errors.As(err, &statusErr) {
+		return statusErr.Status, true
+	}
+
+	return 0, false
+}
+
+// RequestIDOf returns the ID of the request that caused the error, if the error wraps a *StatusError carrying one.
+func RequestIDOf(err error) (string, bool) {
+	if statusErr := new(StatusError); errors.As(err, &statusErr) {
+		return statusErr.RequestID, statusErr.RequestID != ""
+	}
+
+	return "", false
+}
+
+// IsClientError reports whether the error was caused by a response with a 4xx status.
+func IsClientError(err error) bool {
+	status, ok := StatusCodeOf(err)
+	return ok && status >= 400 && status < 500
+}
+
+// IsServerError reports whether the error was caused by a response with a 5xx status.
+func IsServerError(err error) bool {
+	status, ok := StatusCodeOf(err)
+	return ok && status >= 500 && status < 600
+}
+
+// IsRetryable reports whether the error is transient, so the call that returned it may succeed if sent again.
+//
+// The classification is as follows:
+//   - errors of the context (cancellation or deadline) are not retryable: the caller gave up.
+//   - a *StatusError is retryable if the client would retry its status: either the decider of the client says so
+//     (see WithRetryDecider), or the status is one of its retryable statuses (429, 502 and 503 by default, see
+//     WithRetryableStatuses). Other 4xx statuses are not retryable by default, and 422 never is.
+//   - transport errors, when no response was received, are retryable.
+//   - any other error, like an invalid argument, ErrCircuitOpen or a decoding error, is not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if statusErr := new(StatusError); errors.As(err, &statusErr) {
+		return statusErr.retryable
+	}
+
+	// The HTTP client reports the failures of the transport as *url.Error.
+	if urlErr := new(url.Error); errors.As(err, &urlErr) {
+		return true
+	}
+
+	return false
+}
+
+// RateLimitedError is returned when the Gen-API service rejects a call with a 429 status, because too many requests
+// were sent.
+type RateLimitedError struct {
+	// How long to wait before sending a new request, as advertised by the Retry-After header of the response. It is
+	// zero if the server did not provide this information.
+	RetryAfter time.Duration
+}
+
+func (err *RateLimitedError) Error() string {
+	if err.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s", err.RetryAfter)
+	}
+
+	return "rate limited"
+}
+
+// ValidationError details why a text is not a valid log line. It wraps ErrInvalidLogLine.
+type ValidationError struct {
+	// Human-readable explanations of why the log line is invalid.
+	Reasons []string `json:"reasons,omitempty"`
+	// Specific issues, indexed by the name of the offending property of the log line.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (err *ValidationError) Error() string {
+	if len(err.Reasons) == 0 {
+		return ErrInvalidLogLine.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidLogLine, strings.Join(err.Reasons, "; "))
+}
+
+func (err *ValidationError) Unwrap() error {
+	return ErrInvalidLogLine
+}
+
+// Reads the details of a 422 response. ErrInvalidLogLine is returned as-is when the response comes without details.
+func (cfg *config) validationErrorFromResponse(res *http.Response) error {
+	details := new(ValidationError)
+	if err := cfg.decode(res, details); err != nil {
+		return ErrInvalidLogLine
+	}
+
+	if len(details.Reasons) == 0 && len(details.Fields) == 0 {
+		return ErrInvalidLogLine
+	}
+
+	return details
+}
+
+// Parses the value of a Retry-After header, which is either a number of seconds, or an HTTP date. False is returned
+// if the header is missing or malformed.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+
+	// A date in the past means the client can retry right away.
+	return max(date.Sub(now), 0), true
+}
+
+// APIError is the structured error described by the body of an error response of the Gen-API service. It is wrapped
+// by the *StatusError of the response, when the body is a valid error envelope.
+type APIError struct {
+	// Machine-readable code of the error.
+	Code string `json:"code"`
+	// Human-readable description of the error.
+	Message string `json:"message"`
+	// Additional information about the error, specific to its code.
+	Details map[string]any `json:"details,omitempty"`
+	// The status of the response.
+	Status int `json:"-"`
+}
+
+func (err *APIError) Error() string {
+	switch {
+	case err.Code == "":
+		return err.Message
+	case err.Message == "":
+		return err.Code
+	default:
+		return fmt.Sprintf("%s: %s", err.Code, err.Message)
+	}
+}
+
+// Reads the error envelope from the body of an error response. Nil is returned if the body is not a valid envelope.
+func (cfg *config) apiErrorFromBody(status int, body []byte) *APIError {
+	apiErr := &APIError{Status: status}
+	if err := cfg.codec.Unmarshal(body, apiErr); err != nil {
+		return nil
+	}
+
+	if apiErr.Code == "" && apiErr.Message == "" {
+		return nil
+	}
+
+	return apiErr
+}
+
+// Builds the error returned for a response that does not have the expected status. The body of the response is used
+// to describe the error, and the result is wrapped in a *StatusError. If the body is a structured error envelope, the
+// error also wraps an *APIError.
+//
+// A 422 status means the server rejected the log line sent, so the error wraps ErrInvalidLogLine, with the details
+// of the response when available.
+func (cfg *config) responseError(res *http.Response, statusErr error) error {
+	wrapped := &StatusError{
+		Status:    res.StatusCode,
+		RequestID: responseRequestID(res),
+		retryable: cfg.retry.retryableStatus(res.StatusCode),
+	}
+
+	// The body is read once, so it can be parsed both as an error envelope, and by the other parsers.
+	body, _ := io.ReadAll(res.Body)
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if res.StatusCode == http.StatusUnprocessableEntity {
+		wrapped.Err = errors.Join(statusErr, cfg.validationErrorFromResponse(res))
+	} else {
+		wrapped.Err = errors.Join(statusErr, gatewayutils.GetResponseError(res))
+	}
+
+	if apiErr := cfg.apiErrorFromBody(res.StatusCode, body); apiErr != nil {
+		wrapped.Err = errors.Join(wrapped.Err, apiErr)
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := parseRetryAfter(res.Header.Get("Retry-After"), cfg.clock.Now())
+		wrapped.Err = errors.Join(wrapped.Err, &RateLimitedError{RetryAfter: retryAfter})
+	}
+
+	return wrapped
+}
@@ -0,0 +1,275 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Returns the error of a create call answered with the given status by a fake server.
+func createErrorWithStatus(t *testing.T, status int, opts ...Option) error {
+	t.Helper()
+
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+		Status: status,
+		Body:   map[string]string{"error": http.StatusText(status)},
+	})
+
+	_, _, err := NewCreateLogLineAPI(server.URL, opts...).Call(context.Background(), "an instruction", nil)
+	if err == nil {
+		t.Fatalf("expected an error for status %d", status)
+	}
+
+	return err
+}
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "Nil", err: nil, expected: false},
+		{name: "Canceled", err: context.Canceled, expected: false},
+		{name: "DeadlineExceeded", err: fmt.Errorf("call: %w", context.DeadlineExceeded), expected: false},
+		{name: "Transport", err: &url.Error{Op: "Get", URL: "http://x", Err: errors.New("refused")}, expected: true},
+		{name: "InvalidArgument", err: fmt.Errorf("%w: empty", ErrInvalidArgument), expected: false},
+		{name: "CircuitOpen", err: ErrCircuitOpen, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := IsRetryable(testCase.err); got != testCase.expected {
+				t.Errorf("expected IsRetryable to be %t, got %t", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		opts     []Option
+		expected bool
+	}{
+		{name: "ServiceUnavailable", status: http.StatusServiceUnavailable, expected: true},
+		{name: "TooManyRequests", status: http.StatusTooManyRequests, expected: true},
+		{name: "BadRequest", status: http.StatusBadRequest, expected: false},
+		{name: "UnprocessableEntity", status: http.StatusUnprocessableEntity, expected: false},
+		{name: "InternalServerError", status: http.StatusInternalServerError, expected: false},
+		{
+			name:     "CustomStatuses",
+			status:   http.StatusInternalServerError,
+			opts:     []Option{WithRetryableStatuses(http.StatusInternalServerError)},
+			expected: true,
+		},
+		{
+			name:   "DeciderAccepts",
+			status: http.StatusBadRequest,
+			opts: []Option{WithRetryDecider(func(status int, _ error) bool {
+				return status == http.StatusBadRequest
+			})},
+			expected: true,
+		},
+		{
+			name:     "DeciderRejects",
+			status:   http.StatusServiceUnavailable,
+			opts:     []Option{WithRetryDecider(func(int, error) bool { return false })},
+			expected: false,
+		},
+		{
+			name:     "DeciderCannotRetryInvalid",
+			status:   http.StatusUnprocessableEntity,
+			opts:     []Option{WithRetryDecider(func(int, error) bool { return true })},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := createErrorWithStatus(t, testCase.status, testCase.opts...)
+
+			if got := IsRetryable(err); got != testCase.expected {
+				t.Errorf("expected IsRetryable to be %t, got %t (%v)", testCase.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestStatusErrorThroughWrapping(t *testing.T) {
+	cause := errors.New("unexpected status")
+	statusErr := &StatusError{Status: http.StatusBadGateway, Err: cause, RequestID: "request-id"}
+
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{name: "Direct", err: statusErr},
+		{name: "Wrapped", err: fmt.Errorf("create: %w", statusErr)},
+		{name: "WrappedTwice", err: fmt.Errorf("handler: %w", fmt.Errorf("create: %w", statusErr))},
+		{name: "Joined", err: errors.Join(errors.New("cleanup failed"), statusErr)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var target *StatusError
+			if !errors.As(testCase.err, &target) || target != statusErr {
+				t.Fatalf("expected errors.As to find the *StatusError, got %v", target)
+			}
+
+			if !errors.Is(testCase.err, cause) {
+				t.Error("expected errors.Is to find the cause of the *StatusError")
+			}
+
+			if status, ok := StatusCodeOf(testCase.err); !ok || status != http.StatusBadGateway {
+				t.Errorf("expected status %d, got %d (%t)", http.StatusBadGateway, status, ok)
+			}
+
+			if id, ok := RequestIDOf(testCase.err); !ok || id != "request-id" {
+				t.Errorf("expected request ID %q, got %q (%t)", "request-id", id, ok)
+			}
+
+			if !IsServerError(testCase.err) || IsClientError(testCase.err) {
+				t.Error("expected a server error")
+			}
+		})
+	}
+}
+
+func TestStatusCodeOfWithoutStatus(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{name: "Nil", err: nil},
+		{name: "Plain", err: errors.New("failed")},
+		{name: "Wrapped", err: fmt.Errorf("create: %w", ErrInvalidArgument)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if status, ok := StatusCodeOf(testCase.err); ok {
+				t.Errorf("expected no status, got %d", status)
+			}
+
+			if IsClientError(testCase.err) || IsServerError(testCase.err) {
+				t.Error("expected neither a client nor a server error")
+			}
+		})
+	}
+}
+
+func TestStatusErrorOfResponse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		expected error
+	}{
+		{name: "InvalidLogLine", status: http.StatusUnprocessableEntity, expected: ErrInvalidLogLine},
+		{name: "NotFound", status: http.StatusNotFound, expected: nil},
+		{name: "InternalServerError", status: http.StatusInternalServerError, expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(http.MethodPost, "/api/v1/log-lines", testutil.FakeResponse{
+				Status: testCase.status,
+				Body:   map[string]string{"error": http.StatusText(testCase.status)},
+			})
+
+			_, err := NewValidateLogLineAPI(server.URL).Call(context.Background(), "a log line")
+			err = fmt.Errorf("validate: %w", err)
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("expected a *StatusError, got %v", err)
+			}
+
+			if statusErr.Status != testCase.status || statusErr.StatusCode() != testCase.status {
+				t.Errorf("expected status %d, got %d", testCase.status, statusErr.Status)
+			}
+
+			if testCase.expected != nil && !errors.Is(err, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, err)
+			}
+
+			if IsClientError(err) != (testCase.status < 500) || IsServerError(err) != (testCase.status >= 500) {
+				t.Errorf("unexpected classification of status %d", testCase.status)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "Seconds", header: "120", expected: 2 * time.Minute, ok: true},
+		{name: "SecondsWithSpaces", header: " 5 ", expected: 5 * time.Second, ok: true},
+		{name: "ZeroSeconds", header: "0", expected: 0, ok: true},
+		{name: "NegativeSeconds", header: "-1", ok: false},
+		{name: "Date", header: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second, ok: true},
+		{name: "PastDate", header: now.Add(-time.Hour).Format(http.TimeFormat), expected: 0, ok: true},
+		{name: "Missing", header: "", ok: false},
+		{name: "Malformed", header: "soon", ok: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(testCase.header, now)
+			if ok != testCase.ok || delay != testCase.expected {
+				t.Errorf("expected %s (%t), got %s (%t)", testCase.expected, testCase.ok, delay, ok)
+			}
+		})
+	}
+}
+
+func TestRateLimitedError(t *testing.T) {
+	testCases := []struct {
+		name       string
+		retryAfter string
+		expected   time.Duration
+	}{
+		{name: "RetryAfter", retryAfter: "30", expected: 30 * time.Second},
+		// The error is still returned, without a delay to wait for.
+		{name: "Missing", retryAfter: "", expected: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, sequence := newSequenceServer(t, http.StatusTooManyRequests)
+			sequence.retryAfter = testCase.retryAfter
+
+			_, status, err := NewCreateLogLineAPI(server.URL).Call(context.Background(), "an instruction", nil)
+			if status != http.StatusTooManyRequests {
+				t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, status)
+			}
+
+			var rateLimited *RateLimitedError
+			if !errors.As(err, &rateLimited) {
+				t.Fatalf("expected a *RateLimitedError, got %v", err)
+			}
+
+			if rateLimited.RetryAfter != testCase.expected {
+				t.Errorf("expected to retry after %s, got %s", testCase.expected, rateLimited.RetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrNotModified is returned by a conditional create call (see WithIfNoneMatch), when the server answers with a 304
+// status: the log line identified by the ETag is still up-to-date, and nothing was generated.
+var ErrNotModified = errors.New("not modified")
+
+// Key of the If-None-Match value in a context.
+type ifNoneMatchKey struct{}
+
+// WithIfNoneMatch makes the create calls made with this context conditional: the given ETag, returned by a previous
+// call (see CreateMeta), is sent in the If-None-Match header, and the call fails with ErrNotModified, along with a
+// 304 status, if the server has nothing new to generate. This avoids paying for the same generation twice.
+//
+// It applies to the Call, CallWithOptions and CreateWithMeta methods of CreateLogLineAPI.
+func WithIfNoneMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifNoneMatchKey{}, etag)
+}
+
+// Returns the If-None-Match value of the context, if any.
+func ifNoneMatch(ctx context.Context) (string, bool) {
+	etag, ok := ctx.Value(ifNoneMatchKey{}).(string)
+	return etag, ok && etag != ""
+}
+
+// Key of the response headers captured for a call, in a context.
+type responseHeaderKey struct{}
+
+// Returns a context whose call stores the headers of its successful response in the returned value.
+func captureResponseHeader(ctx context.Context) (context.Context, *http.Header) {
+	header := new(http.Header)
+	return context.WithValue(ctx, responseHeaderKey{}, header), header
+}
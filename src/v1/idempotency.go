@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"context"
+)
+
+// Header used to send the idempotency key of a create request to the Gen-API service.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Key of the idempotency key in a context.
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey attaches an idempotency key to the context. Create calls made with this context send the key to
+// the Gen-API service, using the Idempotency-Key header, so the server generates the log line only once, even if the
+// request is received multiple times.
+//
+// The key identifies a single logical call: every attempt of the call sends the same key. Do not use the same key
+// for different calls. When retries are enabled (see WithRetry), a key is generated for each create call whose
+// context does not carry one.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// Returns the idempotency key to send with a create call, if any. A new key is generated when the context does not
+// carry one and the call may be retried.
+func (cfg *config) idempotencyKey(ctx context.Context) (string, bool) {
+	if key, ok := ctx.Value(idempotencyKeyKey{}).(string); ok && key != "" {
+		return key, true
+	}
+
+	if cfg.retry.maxAttempts < 2 {
+		return "", false
+	}
+
+	return newUUID(), true
+}
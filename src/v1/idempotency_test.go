@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Returns the idempotency keys of the requests received by the sequence so far.
+func (sequence *statusSequence) idempotencyKeys() []string {
+	sequence.mu.Lock()
+	defer sequence.mu.Unlock()
+
+	keys := make([]string, len(sequence.headers))
+	for i, header := range sequence.headers {
+		keys[i] = header.Get(idempotencyKeyHeader)
+	}
+
+	return keys
+}
+
+func TestIdempotencyKeyIsReusedAcrossRetries(t *testing.T) {
+	testCases := []struct {
+		name string
+		// Key attached to the context of the call, if any.
+		key string
+	}{
+		{name: "Generated"},
+		{name: "FromContext", key: "my-key"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, sequence := newSequenceServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+			clock := testutil.NewFakeClock(time.Now())
+			autoAdvance(t, clock)
+
+			api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Second), WithClock(clock))
+
+			ctx := context.Background()
+			if testCase.key != "" {
+				ctx = WithIdempotencyKey(ctx, testCase.key)
+			}
+
+			if _, _, err := api.Call(ctx, "an instruction", nil); err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			keys := sequence.idempotencyKeys()
+			if len(keys) != 3 {
+				t.Fatalf("expected 3 attempts, got %d", len(keys))
+			}
+
+			if keys[0] == "" || (testCase.key != "" && keys[0] != testCase.key) {
+				t.Fatalf("unexpected idempotency key %q", keys[0])
+			}
+
+			for i, key := range keys[1:] {
+				if key != keys[0] {
+					t.Errorf("expected attempt %d to send the key %q, got %q", i+2, keys[0], key)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyPerCall(t *testing.T) {
+	server, sequence := newSequenceServer(t)
+
+	api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Second))
+
+	for range 2 {
+		if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+			t.Fatalf("failed to create a log line: %v", err)
+		}
+	}
+
+	keys := sequence.idempotencyKeys()
+	if keys[0] == "" || keys[0] == keys[1] {
+		t.Errorf("expected each call to generate its own key, got %q", keys)
+	}
+}
+
+func TestIdempotencyKeyWithoutRetries(t *testing.T) {
+	server, sequence := newSequenceServer(t)
+
+	if _, _, err := NewCreateLogLineAPI(server.URL).Call(context.Background(), "an instruction", nil); err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	if keys := sequence.idempotencyKeys(); keys[0] != "" {
+		t.Errorf("expected no idempotency key for a call that is not retried, got %q", keys[0])
+	}
+}
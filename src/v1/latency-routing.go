@@ -0,0 +1,195 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Weight of the latest probe in the rolling latency of an endpoint.
+const latencySmoothing = 0.3
+
+// WithLatencyAwareRouting sends each call to the healthy endpoint with the lowest latency, instead of spreading the
+// calls across the endpoints. The latency of every endpoint is probed with a ping, each probeInterval, and smoothed
+// over the latest probes. Calls are still spread in a round-robin fashion until the first probes complete, or when no
+// endpoint could be probed.
+//
+// Probes only measure the latency of the endpoints: they are not rate limited (see WithRateLimit), do not affect the
+// circuit breaker or the health of the endpoints, and are not reported to the logger, the metrics or the other hooks.
+//
+// Probing starts with the first call of the client, and stops once the client is closed: Client.Close must be called
+// when the client is not needed anymore, otherwise the endpoints are probed for the life of the process. This option
+// has no effect on a client with a single endpoint, or with a probeInterval that is not positive. The waits between
+// probes follow the clock of the client (see WithClock).
+func WithLatencyAwareRouting(probeInterval time.Duration) Option {
+	return func(cfg *config) {
+		// Without a positive interval, the endpoints would be probed in a tight loop.
+		if probeInterval <= 0 {
+			return
+		}
+
+		cfg.latencyRouting = &latencyRouter{interval: probeInterval, estimates: make(map[string]time.Duration)}
+	}
+}
+
+// Tracks the latency of the endpoints. A nil latencyRouter tracks nothing.
+type latencyRouter struct {
+	// Delay between two probes of the endpoints.
+	interval time.Duration
+	// Starts probing the endpoints, on first use.
+	probing sync.Once
+
+	mu sync.Mutex
+	// Rolling latency of the endpoints. Endpoints that could not be reached are missing.
+	estimates map[string]time.Duration
+}
+
+// Records the latency of a successful ping to the endpoint.
+func (router *latencyRouter) observe(endpoint string, latency time.Duration) {
+	if router == nil {
+		return
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	previous, ok := router.estimates[endpoint]
+	if !ok {
+		router.estimates[endpoint] = latency
+		return
+	}
+
+	router.estimates[endpoint] = previous + time.Duration(latencySmoothing*float64(latency-previous))
+}
+
+// Discards the latency of an endpoint that could not be reached, so it is not preferred anymore.
+func (router *latencyRouter) forget(endpoint string) {
+	if router == nil {
+		return
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	delete(router.estimates, endpoint)
+}
+
+// Returns the endpoint with the lowest latency among the eligible ones. False is returned if none of them has a
+// known latency.
+func (router *latencyRouter) fastest(endpoints []string, eligible func(endpoint string) bool) (string, bool) {
+	if router == nil {
+		return "", false
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	var (
+		best        string
+		bestLatency time.Duration
+		found       bool
+	)
+
+	for _, endpoint := range endpoints {
+		latency, ok := router.estimates[endpoint]
+		if !ok || !eligible(endpoint) {
+			continue
+		}
+
+		if !found || latency < bestLatency {
+			best, bestLatency, found = endpoint, latency, true
+		}
+	}
+
+	return best, found
+}
+
+// Starts probing the latency of the endpoints in the background, unless already started, or latency-aware routing
+// is disabled.
+func (cfg *config) startProbes() {
+	if cfg.latencyRouting == nil {
+		return
+	}
+
+	cfg.latencyRouting.probing.Do(func() {
+		go cfg.probeEndpoints()
+	})
+}
+
+// Pings every endpoint, right away and then every interval, until the client is closed. The latency of the endpoints
+// is updated by the pings.
+func (cfg *config) probeEndpoints() {
+	interval := cfg.latencyRouting.interval
+
+	for {
+		for _, endpoint := range cfg.endpoints {
+			// A probe slower than the interval is not worth waiting for.
+			if err := cfg.probeLatency(endpoint, interval); errors.Is(err, ErrClientClosed) {
+				return
+			}
+		}
+
+		<-cfg.clock.After(interval)
+	}
+}
+
+// Times a ping to the endpoint, and records its latency, or forgets it if the ping fails. Unlike the pings of
+// PingAPI, probes only feed the latency of the endpoints: they bypass the rate limiter, the circuit breaker and the
+// health of the endpoints, and are not reported to the logger, the metrics or the other hooks of the client.
+func (cfg *config) probeLatency(endpoint string, timeout time.Duration) error {
+	done, err := cfg.calls.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := cfg.newRequest(ctx, endpoint, http.MethodGet, "/ping", nil)
+	if err != nil {
+		cfg.latencyRouting.forget(endpoint)
+		return err
+	}
+
+	start := cfg.clock.Now()
+	res, err := cfg.httpClient.Do(req)
+	latency := cfg.clock.Now().Sub(start)
+	if err != nil {
+		cfg.latencyRouting.forget(endpoint)
+		return err
+	}
+
+	// Drain the body, so the connection can be reused by the next probe.
+	_, _ = io.Copy(io.Discard, cfg.limitBody(res.Body))
+	_ = res.Body.Close()
+
+	if err := gatewayutils.EnsureStatus(res, http.StatusOK); err != nil {
+		cfg.latencyRouting.forget(endpoint)
+		return err
+	}
+
+	cfg.latencyRouting.observe(endpoint, latency)
+
+	return nil
+}
+
+// EndpointLatencies returns the rolling latency of each endpoint, as probed by latency-aware routing (see
+// WithLatencyAwareRouting). Endpoints that were not probed yet, or could not be reached, are missing. The map is
+// empty when latency-aware routing is disabled.
+func (client *Client) EndpointLatencies() map[string]time.Duration {
+	router := client.cfg.latencyRouting
+	if router == nil {
+		return map[string]time.Duration{}
+	}
+
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	return maps.Clone(router.estimates)
+}
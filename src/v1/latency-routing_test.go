@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Waits until the condition holds, and fails the test if it takes too long.
+func eventually(t *testing.T, condition func() bool, message string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLatencyAwareRoutingProbesFollowClock(t *testing.T) {
+	first, second := testutil.NewFakeServer(), testutil.NewFakeServer()
+	defer first.Close()
+	defer second.Close()
+
+	clock := testutil.NewFakeClock(time.Now())
+
+	client, err := NewClientWithEndpoints(
+		[]string{first.URL, second.URL}, WithClock(clock), WithLatencyAwareRouting(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	pings := func() int {
+		return countRequests(first, http.MethodGet, "/ping") + countRequests(second, http.MethodGet, "/ping")
+	}
+
+	// The first call starts probing: each endpoint is probed once, on top of the call itself.
+	if _, err := client.Ping.Call(context.Background()); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+
+	eventually(t, func() bool { return pings() == 3 && clock.Waiters() == 1 }, "the endpoints were not probed")
+
+	if got := len(client.EndpointLatencies()); got != 2 {
+		t.Errorf("expected the latency of 2 endpoints, got %d", got)
+	}
+
+	// The next probes only happen once the clock moves.
+	clock.Advance(time.Minute)
+	eventually(t, func() bool { return pings() == 5 && clock.Waiters() == 1 }, "the endpoints were not probed again")
+
+	// Once closed, the client stops probing.
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("failed to close client: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	eventually(t, func() bool { return clock.Waiters() == 0 }, "the probes did not stop")
+
+	if got := pings(); got != 5 {
+		t.Errorf("expected no probe after close, got %d pings", got)
+	}
+}
+
+func TestLatencyAwareRoutingInvalidInterval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "Zero", interval: 0},
+		{name: "Negative", interval: -time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			first, second := testutil.NewFakeServer(), testutil.NewFakeServer()
+			defer first.Close()
+			defer second.Close()
+
+			client, err := NewClientWithEndpoints(
+				[]string{first.URL, second.URL}, WithLatencyAwareRouting(testCase.interval),
+			)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			defer client.Close(context.Background())
+
+			if client.cfg.latencyRouting != nil {
+				t.Fatal("expected latency-aware routing to be disabled")
+			}
+
+			for range 2 {
+				if _, err := client.Ping.Call(context.Background()); err != nil {
+					t.Fatalf("failed to ping: %v", err)
+				}
+			}
+
+			// The calls are spread across the endpoints, and nothing else is sent.
+			firstPings := countRequests(first, http.MethodGet, "/ping")
+			secondPings := countRequests(second, http.MethodGet, "/ping")
+
+			if firstPings != 1 || secondPings != 1 {
+				t.Errorf("expected 1 ping to each endpoint, got %d and %d", firstPings, secondPings)
+			}
+		})
+	}
+}
+
+func TestLatencyProbesBypassCalls(t *testing.T) {
+	first, second := testutil.NewFakeServer(), testutil.NewFakeServer()
+	defer first.Close()
+	defer second.Close()
+
+	clock := testutil.NewFakeClock(time.Now())
+	reg := prometheus.NewRegistry()
+
+	client, err := NewClientWithEndpoints(
+		[]string{first.URL, second.URL},
+		WithClock(clock),
+		WithLatencyAwareRouting(time.Minute),
+		WithCircuitBreaker(1, time.Hour),
+		// A single token: a probe waiting on the limiter would block the next one.
+		WithRateLimit(0.001, 1),
+		WithMetrics(reg),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close(context.Background())
+
+	// Open the breaker, as a failed call would.
+	client.cfg.breaker.record(clock.Now(), &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, false)
+
+	client.cfg.startProbes()
+
+	pings := func() int {
+		return countRequests(first, http.MethodGet, "/ping") + countRequests(second, http.MethodGet, "/ping")
+	}
+
+	eventually(t, func() bool { return pings() == 2 && clock.Waiters() == 1 }, "the endpoints were not probed")
+
+	if got := len(client.EndpointLatencies()); got != 2 {
+		t.Errorf("expected the latency of 2 endpoints, got %d", got)
+	}
+
+	// A successful probe does not close the breaker.
+	if err := client.cfg.breaker.allow(clock.Now()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the breaker to stay open, got %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather the metrics: %v", err)
+	}
+
+	if len(families) > 0 {
+		t.Errorf("expected the probes not to be recorded, got %d metrics", len(families))
+	}
+}
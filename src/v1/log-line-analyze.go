@@ -0,0 +1,197 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Body of a request analyzing a log line.
+type analyzeLogLineRequest struct {
+	LogLine string `json:"logLine"`
+}
+
+// Body of a score response.
+type scoreLogLineResponse struct {
+	Score float64 `json:"score"`
+}
+
+// Body of a language detection response. It is also the result of the detection mocks.
+type detectLanguageResponse struct {
+	// BCP 47 tag of the detected language.
+	Lang       string  `json:"lang" yaml:"lang"`
+	Confidence float64 `json:"confidence" yaml:"confidence"`
+}
+
+// Body of a keywords response.
+type extractKeywordsResponse struct {
+	Keywords []string `json:"keywords"`
+}
+
+// AnalyzeLogLineAPI sends requests to describe an existing log line.
+type AnalyzeLogLineAPI interface {
+	// Score rates the quality of a log line, from 0 (worst) to 1 (best), so candidates can be ranked. It returns the
+	// score, along with the status of the response and error, if any.
+	//
+	// Like for ValidateLogLineAPI, a 422 status, along with ErrInvalidLogLine, is returned if the input is not a log
+	// line. A score outside of [0, 1] is reported as an error.
+	Score(ctx context.Context, logLine string) (float64, int, error)
+	// MockScore returns a mocked response of Score, based on the chosen scenario.
+	//
+	// If the scenario has a latency, MockScore waits for it before returning, or returns the error of the context if
+	// it is done first. Like for a real call, the error of the context is returned right away if it is already done.
+	MockScore(ctx context.Context, useCase MockUseCase) (float64, int, error)
+	// DetectLanguage detects the language of a text. It returns the BCP 47 tag of the language (for example "fr"),
+	// and the confidence of the detection, from 0 to 1.
+	//
+	// An ambiguous text is not an error: the best guess is returned, with a low confidence. Callers should check the
+	// confidence before relying on the language.
+	DetectLanguage(ctx context.Context, logLine string) (string, float64, int, error)
+	// MockDetectLanguage returns a mocked response of DetectLanguage, based on the chosen scenario. It behaves like
+	// MockScore. Besides the shared use cases, the embedded mocks detect French with the "french" use case, and an
+	// ambiguous text with the "ambiguous" one.
+	MockDetectLanguage(ctx context.Context, useCase MockUseCase) (string, float64, int, error)
+	// ExtractKeywords returns the salient keywords and themes of a log line, for tagging. The keywords are trimmed,
+	// and the empty ones are dropped.
+	ExtractKeywords(ctx context.Context, logLine string) ([]string, int, error)
+	// MockExtractKeywords returns a mocked response of ExtractKeywords, based on the chosen scenario. It behaves like
+	// MockScore.
+	MockExtractKeywords(ctx context.Context, useCase MockUseCase) ([]string, int, error)
+}
+
+// Implements the AnalyzeLogLineAPI interface.
+type analyzeLogLineAPI struct {
+	// Configuration of the client.
+	cfg *config
+	// Mocked responses. The embedded ones are used if nil.
+	mocks *logLineMocks
+}
+
+func (api *analyzeLogLineAPI) Score(ctx context.Context, logLine string) (float64, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return 0, 0, err
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.ScoreLogLine")
+	span.setAttributes(attribute.Int("gen_api.log_line.length", len(logLine)))
+
+	// A 422 status is turned into ErrInvalidLogLine by the shared error handling.
+	responseBody, status, err := doJSON[analyzeLogLineRequest, scoreLogLineResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/score"),
+		analyzeLogLineRequest{LogLine: logLine},
+		http.StatusOK,
+	)
+	if err == nil && (responseBody.Score < 0 || responseBody.Score > 1) {
+		err = fmt.Errorf("score out of range: %v", responseBody.Score)
+	}
+	span.end(status, err)
+
+	return responseBody.Score, status, err
+}
+
+func (api *analyzeLogLineAPI) MockScore(ctx context.Context, useCase MockUseCase) (float64, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, scoreMocks, useCase)
+}
+
+// Returns the scenarios of MockScore.
+func scoreMocks(mocks *logLineMocks) map[string]resultMock[float64] {
+	return mocks.Score
+}
+
+func (api *analyzeLogLineAPI) DetectLanguage(ctx context.Context, logLine string) (string, float64, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return "", 0, 0, err
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.DetectLogLineLanguage")
+	span.setAttributes(attribute.Int("gen_api.log_line.length", len(logLine)))
+
+	responseBody, status, err := doJSON[analyzeLogLineRequest, detectLanguageResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/language"),
+		analyzeLogLineRequest{LogLine: logLine},
+		http.StatusOK,
+	)
+	span.end(status, err)
+
+	// Return the canonical form of the tag. A tag that cannot be parsed is still the best guess of the server.
+	if tag, parseErr := language.Parse(responseBody.Lang); parseErr == nil {
+		responseBody.Lang = tag.String()
+	}
+
+	return responseBody.Lang, responseBody.Confidence, status, err
+}
+
+func (api *analyzeLogLineAPI) MockDetectLanguage(
+	ctx context.Context, useCase MockUseCase,
+) (string, float64, int, error) {
+	detected, status, err := mockResult(ctx, api.cfg, api.mocks, detectLanguageMocks, useCase)
+	return detected.Lang, detected.Confidence, status, err
+}
+
+// Returns the scenarios of MockDetectLanguage.
+func detectLanguageMocks(mocks *logLineMocks) map[string]resultMock[detectLanguageResponse] {
+	return mocks.DetectLanguage
+}
+
+func (api *analyzeLogLineAPI) ExtractKeywords(ctx context.Context, logLine string) ([]string, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return nil, 0, err
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.ExtractLogLineKeywords")
+	span.setAttributes(attribute.Int("gen_api.log_line.length", len(logLine)))
+
+	responseBody, status, err := doJSON[analyzeLogLineRequest, extractKeywordsResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/keywords"),
+		analyzeLogLineRequest{LogLine: logLine},
+		http.StatusOK,
+	)
+	span.end(status, err)
+
+	if err != nil {
+		return nil, status, err
+	}
+
+	keywords := make([]string, 0, len(responseBody.Keywords))
+	for _, keyword := range responseBody.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	return keywords, status, nil
+}
+
+func (api *analyzeLogLineAPI) MockExtractKeywords(ctx context.Context, useCase MockUseCase) ([]string, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, keywordsMocks, useCase)
+}
+
+// Returns the scenarios of MockExtractKeywords.
+func keywordsMocks(mocks *logLineMocks) map[string]resultMock[[]string] {
+	return mocks.Keywords
+}
+
+// NewAnalyzeLogLineAPI returns a new instance of AnalyzeLogLineAPI.
+//
+// The endpoint is the root URL for accessing the Gen-API service.
+func NewAnalyzeLogLineAPI(endpoint string, opts ...Option) AnalyzeLogLineAPI {
+	return &analyzeLogLineAPI{cfg: newConfig([]string{endpoint}, opts)}
+}
+
+// NewAnalyzeLogLineAPIWithMocks returns a new instance of AnalyzeLogLineAPI, whose Mock methods also use the
+// scenarios read from the given YAML source.
+//
+// The source uses the same format as the mocks embedded in this package. Its scenarios are merged over the embedded
+// ones, so a use case can be replaced by declaring it again. An error is returned if the source is not valid YAML.
+func NewAnalyzeLogLineAPIWithMocks(endpoint string, source io.Reader, opts ...Option) (AnalyzeLogLineAPI, error) {
+	scenarios, err := readMocks(source)
+	if err != nil {
+		return nil, fmt.Errorf("read mocks: %w", err)
+	}
+
+	return &analyzeLogLineAPI{cfg: newConfig([]string{endpoint}, opts), mocks: scenarios}, nil
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	testCases := []struct {
+		name  string
+		score float64
+	}{
+		{name: "Worst", score: 0},
+		{name: "Best", score: 1},
+		{name: "Half", score: 0.5},
+		{name: "Precise", score: 0.123456789012345},
+		{name: "Tiny", score: 1e-12},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(http.MethodPost, "/api/v1/log-lines/score", testutil.FakeResponse{
+				Status: http.StatusOK,
+				Body:   map[string]float64{"score": testCase.score},
+			})
+
+			score, status, err := NewAnalyzeLogLineAPI(server.URL).Score(context.Background(), "a log line")
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("failed to score: %d: %v", status, err)
+			}
+
+			if score != testCase.score {
+				t.Errorf("expected the score %v, got %v", testCase.score, score)
+			}
+
+			var body analyzeLogLineRequest
+			if err := json.Unmarshal([]byte(server.Requests()[0].Body), &body); err != nil {
+				t.Fatalf("failed to decode the request: %v", err)
+			}
+
+			if body.LogLine != "a log line" {
+				t.Errorf("expected the log line to be sent, got %q", body.LogLine)
+			}
+		})
+	}
+}
+
+func TestScoreErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response testutil.FakeResponse
+		expected error
+	}{
+		{
+			name:     "InvalidLogLine",
+			response: testutil.FakeResponse{Status: http.StatusUnprocessableEntity},
+			expected: ErrInvalidLogLine,
+		},
+		{
+			name:     "OutOfRange",
+			response: testutil.FakeResponse{Status: http.StatusOK, Body: map[string]float64{"score": 1.5}},
+		},
+		{
+			name:     "Negative",
+			response: testutil.FakeResponse{Status: http.StatusOK, Body: map[string]float64{"score": -0.1}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(http.MethodPost, "/api/v1/log-lines/score", testCase.response)
+
+			_, status, err := NewAnalyzeLogLineAPI(server.URL).Score(context.Background(), "a log line")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if status != testCase.response.Status {
+				t.Errorf("expected status %d, got %d", testCase.response.Status, status)
+			}
+
+			if testCase.expected != nil && !errors.Is(err, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestScoreEmptyLogLine(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	_, _, err := NewAnalyzeLogLineAPI(server.URL).Score(context.Background(), " ")
+	if !errors.Is(err, ErrEmptyLogLine) {
+		t.Errorf("expected ErrEmptyLogLine, got %v", err)
+	}
+
+	if requests := server.Requests(); len(requests) > 0 {
+		t.Errorf("expected no request to be sent, got %d", len(requests))
+	}
+}
@@ -0,0 +1,323 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CreateRequest describes one of the log lines to generate in a batch.
+type CreateRequest struct {
+	Instruction string
+	Remix       []string
+	Options     CreateOptions
+}
+
+// CreateResult is the outcome of one of the requests of a batch.
+type CreateResult struct {
+	// The generated log line.
+	LogLine string
+	// The status of the response, or 0 if no response was received.
+	Status int
+	Err    error
+	// Whether the request was never sent, because the batch stopped before its turn: the context of the batch was
+	// done, or another request failed (see BatchOptions.StopOnError). Err tells which.
+	Skipped bool
+}
+
+// ErrBatchStopped is wrapped by the error of the requests of a batch that were skipped, or cancelled while in flight,
+// because another request failed (see BatchOptions.StopOnError). The request that failed first does not wrap it.
+var ErrBatchStopped = errors.New("batch stopped after a failure")
+
+// BatchOptions tunes how a batch is processed.
+type BatchOptions struct {
+	// Maximum number of requests sent at a time. It defaults to 1.
+	Concurrency int
+	// Stops the batch on the first failed request: the requests in flight are cancelled, with an error wrapping
+	// ErrBatchStopped, and the ones not sent yet are skipped, with ErrBatchStopped. Requests run concurrently, so which
+	// request fails first, and how many complete before the batch stops, may change from one run to another.
+	StopOnError bool
+	// Shares the time left before the deadline of the context of the batch between its requests, so a few slow
+	// requests cannot use it all. It defaults to FairBatchBudget. It is not used when the context has no deadline.
+	Budget BatchBudget
+}
+
+// BatchBudget returns the timeout of the next request of a batch, given the time left before the deadline of the
+// batch, the number of requests not sent yet (including the next one), and the number of requests sent at a time.
+type BatchBudget func(remaining time.Duration, pending, concurrency int) time.Duration
+
+// FairBatchBudget splits the time left evenly between the waves of requests still to send: with 10 pending requests,
+// sent 2 at a time, each request gets a fifth of the time left.
+func FairBatchBudget(remaining time.Duration, pending, concurrency int) time.Duration {
+	waves := (pending + concurrency - 1) / max(concurrency, 1)
+	return remaining / time.Duration(max(waves, 1))
+}
+
+func (api *createLogLineAPI) CreateBatch(ctx context.Context, reqs []CreateRequest, concurrency int) []CreateResult {
+	return api.CreateBatchWithOptions(ctx, reqs, BatchOptions{Concurrency: concurrency})
+}
+
+func (api *createLogLineAPI) CreateBatchWithOptions(
+	ctx context.Context, reqs []CreateRequest, opts BatchOptions,
+) []CreateResult {
+	results := make([]CreateResult, len(reqs))
+
+	concurrency := max(opts.Concurrency, 1)
+
+	budget := opts.Budget
+	if budget == nil {
+		budget = FairBatchBudget
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+
+	// Cancelled with ErrBatchStopped to stop the batch on the first failure.
+	batchCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Holds a token for each request in flight.
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+
+	// Marks the requests from the given one on as skipped.
+	skipFrom := func(first int) {
+		err := ctx.Err()
+		if err == nil {
+			err = ErrBatchStopped
+		}
+
+		for i := first; i < len(reqs); i++ {
+			results[i] = CreateResult{Err: err, Skipped: true}
+		}
+	}
+
+	for i, req := range reqs {
+		select {
+		case slots <- struct{}{}:
+		case <-batchCtx.Done():
+		}
+
+		// Stop dispatching: the remaining requests are never sent.
+		if batchCtx.Err() != nil {
+			skipFrom(i)
+			wg.Wait()
+
+			return results
+		}
+
+		reqCtx, reqCancel := batchCtx, context.CancelFunc(func() {})
+		if hasDeadline {
+			reqCtx, reqCancel = context.WithTimeout(batchCtx, budget(time.Until(deadline), len(reqs)-i, concurrency))
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer reqCancel()
+
+			// Requests of a batch are meant to be sent separately, even when identical.
+			logLine, status, err := api.callWithOptions(reqCtx, req.Instruction, req.Remix, req.Options, nil)
+			// Tell the requests interrupted by the failure of another one from the failure itself.
+			if errors.Is(err, context.Canceled) && errors.Is(context.Cause(batchCtx), ErrBatchStopped) {
+				err = fmt.Errorf("%w: %w", ErrBatchStopped, err)
+			}
+
+			results[i] = CreateResult{LogLine: logLine, Status: status, Err: err}
+
+			if err != nil && opts.StopOnError {
+				cancel(ErrBatchStopped)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// Body of a batch validate request.
+type validateLogLinesRequest struct {
+	LogLines []string `json:"logLines"`
+}
+
+// Body of a batch validate response.
+type validateLogLinesResponse struct {
+	Results []struct {
+		Valid bool `json:"valid"`
+		ValidationError
+	} `json:"results"`
+}
+
+func (api *validateLogLineAPI) ValidateBatch(ctx context.Context, logLines []string) ([]error, int, error) {
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.ValidateLogLines")
+
+	responseBody, status, err := doJSON[validateLogLinesRequest, validateLogLinesResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/batch"),
+		validateLogLinesRequest{LogLines: logLines},
+		http.StatusOK,
+	)
+	if err == nil && len(responseBody.Results) != len(logLines) {
+		err = fmt.Errorf("expected %d results, got %d", len(logLines), len(responseBody.Results))
+	}
+	span.end(status, err)
+
+	if err != nil {
+		return nil, status, err
+	}
+
+	errs := make([]error, len(logLines))
+	for i, result := range responseBody.Results {
+		if result.Valid {
+			continue
+		}
+
+		if len(result.Reasons) == 0 && len(result.Fields) == 0 {
+			errs[i] = ErrInvalidLogLine
+			continue
+		}
+
+		errs[i] = &ValidationError{Reasons: result.Reasons, Fields: result.Fields}
+	}
+
+	return errs, status, nil
+}
+
+// BatchError gathers the failures of the items of a batch into a single error, for callers that handle a batch as a
+// whole. Use errors.Is and errors.As to inspect the failures, and Failed to know which items failed.
+type BatchError struct {
+	// Errors of the items of the batch, in order. It is nil for the items that succeeded.
+	Errs []error
+}
+
+// NewBatchError returns a BatchError for the errors of the items of a batch, as returned by
+// ValidateLogLineAPI.ValidateBatch. It returns nil if every item succeeded.
+func NewBatchError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return BatchError{Errs: errs}
+		}
+	}
+
+	return nil
+}
+
+// NewCreateBatchError returns a BatchError for the results of CreateLogLineAPI.CreateBatch. It returns nil if every
+// request succeeded.
+func NewCreateBatchError(results []CreateResult) error {
+	errs := make([]error, len(results))
+	for i, result := range results {
+		errs[i] = result.Err
+	}
+
+	return NewBatchError(errs)
+}
+
+func (err BatchError) Error() string {
+	failed := err.Failed()
+	if len(failed) == 0 {
+		return fmt.Sprintf("0 of %d items failed", len(err.Errs))
+	}
+
+	return fmt.Sprintf(
+		"%d of %d items failed, first at index %d: %s", len(failed), len(err.Errs), failed[0], err.Errs[failed[0]],
+	)
+}
+
+func (err BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(err.Errs))
+	for _, itemErr := range err.Errs {
+		if itemErr != nil {
+			errs = append(errs, itemErr)
+		}
+	}
+
+	return errs
+}
+
+// Failed returns the indices of the items that failed, in ascending order.
+func (err BatchError) Failed() []int {
+	var failed []int
+	for i, itemErr := range err.Errs {
+		if itemErr != nil {
+			failed = append(failed, i)
+		}
+	}
+
+	return failed
+}
+
+// InvalidLogLinesError is returned by ValidateAll when some of the log lines are invalid. It wraps ErrInvalidLogLine.
+type InvalidLogLinesError struct {
+	// Indices of the invalid log lines, in the validated slice, in ascending order.
+	Indices []int
+	// Why each log line is invalid, in the same order as Indices. A *ValidationError explains the issue when the
+	// server provided details.
+	Errs []error
+	// Number of validated log lines.
+	Total int
+}
+
+func (err *InvalidLogLinesError) Error() string {
+	indices := make([]string, len(err.Indices))
+	for i, index := range err.Indices {
+		indices[i] = strconv.Itoa(index)
+	}
+
+	return fmt.Sprintf(
+		"%s: %d of %d log lines are invalid (indices %s)",
+		ErrInvalidLogLine, len(err.Indices), err.Total, strings.Join(indices, ", "),
+	)
+}
+
+func (err *InvalidLogLinesError) Unwrap() error {
+	return ErrInvalidLogLine
+}
+
+func (api *validateLogLineAPI) ValidateAll(ctx context.Context, logLines []string) (int, error) {
+	if len(logLines) == 0 {
+		return 0, fmt.Errorf("%w: no log line to validate", ErrInvalidArgument)
+	}
+
+	errs, status, err := api.ValidateBatch(ctx, logLines)
+	if err != nil {
+		return status, err
+	}
+
+	invalid := &InvalidLogLinesError{Total: len(logLines)}
+	for i, err := range errs {
+		if err != nil {
+			invalid.Indices = append(invalid.Indices, i)
+			invalid.Errs = append(invalid.Errs, err)
+		}
+	}
+
+	if len(invalid.Indices) > 0 {
+		return http.StatusUnprocessableEntity, invalid
+	}
+
+	return http.StatusNoContent, nil
+}
+
+func (api *createLogLineAPI) CreateAsync(
+	ctx context.Context, instruction string, remix []string,
+) <-chan CreateResult {
+	// Buffered, so the goroutine never blocks on a caller that left.
+	result := make(chan CreateResult, 1)
+
+	go func() {
+		defer close(result)
+
+		logLine, status, err := api.Call(ctx, instruction, remix)
+		result <- CreateResult{LogLine: logLine, Status: status, Err: err}
+	}()
+
+	return result
+}
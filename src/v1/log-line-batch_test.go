@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Answers the create requests according to their instruction: "fail" gets a 400 status, "hang" waits until the
+// client gives up, and any other instruction gets a log line.
+func newInstructionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body createLogLineRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch body.Instruction {
+		case "fail":
+			http.Error(w, "a failure", http.StatusBadRequest)
+		case "hang":
+			<-req.Context().Done()
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"logLine":"a fake log line"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCreateBatchStopOnError(t *testing.T) {
+	server := newInstructionServer(t)
+	api := NewCreateLogLineAPI(server.URL)
+
+	// The first 2 requests are sent together: the second one fails while the first one is in flight, and the others
+	// are never sent.
+	reqs := []CreateRequest{{Instruction: "hang"}, {Instruction: "fail"}, {Instruction: "ok"}, {Instruction: "ok"}}
+
+	results := api.CreateBatchWithOptions(context.Background(), reqs, BatchOptions{Concurrency: 2, StopOnError: true})
+
+	testCases := []struct {
+		name    string
+		result  CreateResult
+		stopped bool
+		skipped bool
+	}{
+		{name: "InFlight", result: results[0], stopped: true, skipped: false},
+		{name: "Failure", result: results[1], stopped: false, skipped: false},
+		{name: "NotSent", result: results[2], stopped: true, skipped: true},
+		{name: "LastNotSent", result: results[3], stopped: true, skipped: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.result.Err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if stopped := errors.Is(testCase.result.Err, ErrBatchStopped); stopped != testCase.stopped {
+				t.Errorf("expected ErrBatchStopped: %t, got %v", testCase.stopped, testCase.result.Err)
+			}
+
+			if testCase.result.Skipped != testCase.skipped {
+				t.Errorf("expected skipped: %t, got %t", testCase.skipped, testCase.result.Skipped)
+			}
+		})
+	}
+
+	if status := results[1].Status; status != http.StatusBadRequest {
+		t.Errorf("expected the failure to keep its status, got %d", status)
+	}
+}
+
+func TestCreateBatchWithoutStopOnError(t *testing.T) {
+	server := newInstructionServer(t)
+	api := NewCreateLogLineAPI(server.URL)
+
+	reqs := []CreateRequest{{Instruction: "fail"}, {Instruction: "ok"}, {Instruction: "ok"}}
+
+	results := api.CreateBatchWithOptions(context.Background(), reqs, BatchOptions{Concurrency: 2})
+
+	if results[0].Err == nil || errors.Is(results[0].Err, ErrBatchStopped) {
+		t.Errorf("expected the first request to fail on its own, got %v", results[0].Err)
+	}
+
+	for i, result := range results[1:] {
+		if result.Err != nil || result.LogLine != "a fake log line" {
+			t.Errorf("request %d: expected a log line, got %q: %v", i+1, result.LogLine, result.Err)
+		}
+	}
+}
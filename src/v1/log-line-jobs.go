@@ -0,0 +1,212 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Statuses of an asynchronous generation job, as returned by CreateLogLineAPI.PollResult.
+const (
+	// The job is waiting to be processed.
+	JobPending = "pending"
+	// The job is being processed.
+	JobRunning = "running"
+	// The job completed, and its log line is available.
+	JobSucceeded = "succeeded"
+	// The job failed. It will not produce a log line.
+	JobFailed = "failed"
+)
+
+// ErrJobFailed is wrapped by the errors returned when an asynchronous generation job failed on the server.
+var ErrJobFailed = errors.New("generation job failed")
+
+// Longest delay between two polls of CreateAwait, regardless of the poll interval.
+const maxPollInterval = 30 * time.Second
+
+// Body of a job creation request.
+type createJobRequest struct {
+	createLogLineRequest
+	// URL the server calls once the job completes. See ParseCallbackRequest.
+	CallbackURL string `json:"callbackUrl,omitempty"`
+}
+
+// Body of a job creation response.
+type createJobResponse struct {
+	JobID string `json:"jobId"`
+}
+
+// Body of a job status response.
+type jobResultResponse struct {
+	Status string `json:"status"`
+	// The generated log line, once the job succeeded.
+	LogLine string `json:"logLine,omitempty"`
+	// Why the job failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+func (api *createLogLineAPI) CreateAsyncJob(ctx context.Context, instruction string, remix []string) (string, error) {
+	return api.createJob(ctx, instruction, remix, "")
+}
+
+func (api *createLogLineAPI) CreateAsyncJobWithCallback(
+	ctx context.Context, instruction string, remix []string, callbackURL string,
+) (string, error) {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: invalid callback URL %q", ErrInvalidArgument, callbackURL)
+	}
+
+	return api.createJob(ctx, instruction, remix, callbackURL)
+}
+
+// Starts a generation job. The server calls the callback URL once the job completes, unless empty.
+func (api *createLogLineAPI) createJob(
+	ctx context.Context, instruction string, remix []string, callbackURL string,
+) (string, error) {
+	if err := validateInstruction(instruction); err != nil {
+		return "", err
+	}
+
+	if _, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return "", err
+	}
+
+	remix, err := api.cfg.normalizeRemix(remix)
+	if err != nil {
+		return "", err
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLineJob")
+	span.setAttributes(attribute.Int("gen_api.instruction.length", len(instruction)))
+
+	responseBody, status, err := doJSON[createJobRequest, createJobResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/jobs"),
+		createJobRequest{
+			createLogLineRequest: createLogLineRequest{Instruction: instruction, Remix: remix, N: 1},
+			CallbackURL:          callbackURL,
+		},
+		http.StatusAccepted,
+		editors...,
+	)
+	if err == nil && responseBody.JobID == "" {
+		err = errors.New("no job ID in the response")
+	}
+	span.end(status, err)
+
+	return responseBody.JobID, err
+}
+
+func (api *createLogLineAPI) PollResult(ctx context.Context, jobID string) (string, string, int, error) {
+	if jobID == "" {
+		return "", "", 0, fmt.Errorf("%w: empty job ID", ErrInvalidArgument)
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.PollLogLineJob")
+	span.setAttributes(attribute.String("gen_api.job.id", jobID))
+
+	var responseBody jobResultResponse
+
+	status, err := api.cfg.doJSON(
+		ctx, http.MethodGet, api.cfg.apiPath("/log-lines/jobs/"+url.PathEscape(jobID)), nil, &responseBody,
+		http.StatusOK,
+	)
+	if err == nil && responseBody.Status == JobFailed {
+		err = fmt.Errorf("%w: %s", ErrJobFailed, responseBody.Error)
+	}
+	span.end(status, err)
+
+	return responseBody.LogLine, responseBody.Status, status, err
+}
+
+func (api *createLogLineAPI) CreateAwait(
+	ctx context.Context, instruction string, remix []string, pollInterval time.Duration,
+) (string, int, error) {
+	if pollInterval <= 0 {
+		return "", 0, fmt.Errorf("%w: the poll interval must be positive, got %s", ErrInvalidArgument, pollInterval)
+	}
+
+	jobID, err := api.CreateAsyncJob(ctx, instruction, remix)
+	if err != nil {
+		return "", 0, err
+	}
+
+	delay := pollInterval
+
+	for {
+		if err := api.cfg.sleep(ctx, delay); err != nil {
+			return "", 0, err
+		}
+
+		logLine, jobStatus, status, err := api.PollResult(ctx, jobID)
+		if err != nil || jobStatus == JobSucceeded {
+			return logLine, status, err
+		}
+
+		// Long generations are polled less and less often, so they don't keep the service busy.
+		delay = min(delay*2, max(pollInterval, maxPollInterval))
+	}
+}
+
+// Body of the request sent by the Gen-API service to the callback URL of a job.
+type jobCallbackRequest struct {
+	JobID string `json:"jobId"`
+	jobResultResponse
+}
+
+// ParseCallbackRequest decodes the request sent by the Gen-API service to the callback URL of a job, once the job
+// completes (see CreateLogLineAPI.CreateAsyncJobWithCallback). It returns the ID of the job, and the generated log
+// line.
+//
+// A failed job comes with an error wrapping ErrJobFailed, along with its ID. The body of the request is read, but
+// not closed: this is up to the server. A body larger than 10 MiB fails with ErrResponseTooLarge.
+//
+// The request is decoded with encoding/json. Use CreateLogLineAPI.ParseCallbackRequest to decode it with the Codec of
+// a client instead (see WithCodec).
+func ParseCallbackRequest(r *http.Request) (string, string, error) {
+	return parseCallbackRequest(callbackConfig, r)
+}
+
+func (api *createLogLineAPI) ParseCallbackRequest(r *http.Request) (string, string, error) {
+	return parseCallbackRequest(api.cfg, r)
+}
+
+// Configuration used by ParseCallbackRequest, outside of any client.
+var callbackConfig = &config{codec: jsonCodec{}, maxResponseBytes: defaultMaxResponseBytes}
+
+// Implements ParseCallbackRequest, with the codec and the size limit of the configuration.
+func parseCallbackRequest(cfg *config, r *http.Request) (string, string, error) {
+	// The limit is enforced by the body itself, so a larger body fails instead of being silently truncated.
+	data, err := io.ReadAll(cfg.limitBody(r.Body))
+	if err != nil {
+		return "", "", fmt.Errorf("read callback: %w", err)
+	}
+
+	var payload jobCallbackRequest
+	if err := cfg.codec.Unmarshal(data, &payload); err != nil {
+		return "", "", fmt.Errorf("decode callback: %w", err)
+	}
+
+	if payload.JobID == "" {
+		return "", "", errors.New("decode callback: no job ID")
+	}
+
+	switch payload.Status {
+	case JobSucceeded:
+		return payload.JobID, payload.LogLine, nil
+	case JobFailed:
+		return payload.JobID, "", fmt.Errorf("%w: %s", ErrJobFailed, payload.Error)
+	default:
+		return payload.JobID, "", fmt.Errorf("decode callback: unexpected job status %q", payload.Status)
+	}
+}
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// A Codec that counts the values it decodes.
+type countingCodec struct {
+	jsonCodec
+	decoded atomic.Int32
+}
+
+func (codec *countingCodec) Unmarshal(data []byte, v any) error {
+	codec.decoded.Add(1)
+	return codec.jsonCodec.Unmarshal(data, v)
+}
+
+func TestCreateAsyncJobWithCallback(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	api := NewCreateLogLineAPI(server.URL)
+
+	jobID, err := api.CreateAsyncJobWithCallback(
+		context.Background(), "an instruction", nil, "https://example.com/callback",
+	)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	if jobID != "fake-job" {
+		t.Errorf("expected the ID of the job, got %q", jobID)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Body, `"callbackUrl":"https://example.com/callback"`) {
+		t.Errorf("expected the callback URL to be sent, got %+v", requests)
+	}
+
+	_, err = api.CreateAsyncJobWithCallback(context.Background(), "an instruction", nil, "not-a-url")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for a relative callback URL, got %v", err)
+	}
+}
+
+func TestParseCallbackRequest(t *testing.T) {
+	testCases := []struct {
+		name            string
+		body            string
+		expectedJobID   string
+		expectedLogLine string
+		expectedErr     error
+		expectErr       bool
+	}{
+		{
+			name:            "Succeeded",
+			body:            `{"jobId":"job","status":"succeeded","logLine":"a log line"}`,
+			expectedJobID:   "job",
+			expectedLogLine: "a log line",
+		},
+		{
+			name:          "Failed",
+			body:          `{"jobId":"job","status":"failed","error":"boom"}`,
+			expectedJobID: "job",
+			expectedErr:   ErrJobFailed,
+			expectErr:     true,
+		},
+		{name: "NoJobID", body: `{"status":"succeeded"}`, expectErr: true},
+		{name: "Malformed", body: `{`, expectErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(testCase.body))
+
+			jobID, logLine, err := ParseCallbackRequest(req)
+			if (err != nil) != testCase.expectErr {
+				t.Fatalf("expected error: %t, got %v", testCase.expectErr, err)
+			}
+
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %v", testCase.expectedErr, err)
+			}
+
+			if jobID != testCase.expectedJobID || logLine != testCase.expectedLogLine {
+				t.Errorf(
+					"expected job %q and log line %q, got %q and %q",
+					testCase.expectedJobID, testCase.expectedLogLine, jobID, logLine,
+				)
+			}
+		})
+	}
+}
+
+func TestParseCallbackRequestTooLarge(t *testing.T) {
+	// A valid callback, followed by padding past the limit: it must not be decoded from a truncated body.
+	callback := `{"jobId":"job","status":"succeeded","logLine":"a log line"}`
+
+	testCases := []struct {
+		name  string
+		body  string
+		parse func(r *http.Request) (string, string, error)
+	}{
+		{name: "Default", body: callback + strings.Repeat(" ", 10<<20), parse: ParseCallbackRequest},
+		{
+			name:  "Client",
+			body:  callback + strings.Repeat(" ", 64),
+			parse: NewCreateLogLineAPI("http://localhost", WithMaxResponseBytes(64)).ParseCallbackRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(testCase.body))
+
+			jobID, logLine, err := testCase.parse(req)
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("expected ErrResponseTooLarge, got %v", err)
+			}
+
+			if jobID != "" || logLine != "" {
+				t.Errorf("expected nothing to be decoded, got %q and %q", jobID, logLine)
+			}
+		})
+	}
+}
+
+func TestParseCallbackRequestUsesCodec(t *testing.T) {
+	codec := new(countingCodec)
+	api := NewCreateLogLineAPI("http://localhost", WithCodec(codec))
+
+	req := httptest.NewRequest(
+		http.MethodPost, "/callback", strings.NewReader(`{"jobId":"job","status":"succeeded","logLine":"a log line"}`),
+	)
+
+	jobID, logLine, err := api.ParseCallbackRequest(req)
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+
+	if jobID != "job" || logLine != "a log line" {
+		t.Errorf("expected the job and its log line, got %q and %q", jobID, logLine)
+	}
+
+	if codec.decoded.Load() != 1 {
+		t.Errorf("expected the codec of the client to decode the callback, got %d decodings", codec.decoded.Load())
+	}
+}
@@ -0,0 +1,162 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"net/http"
+)
+
+func (api *createLogLineAPI) CreateManyStream(
+	ctx context.Context, instruction string, remix []string, n int,
+) (<-chan string, <-chan error) {
+	logLines := make(chan string)
+	// Buffered, so the goroutine never blocks on reporting its single error.
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(logLines)
+
+		if err := api.streamMany(ctx, instruction, remix, n, logLines); err != nil {
+			errs <- err
+		}
+	}()
+
+	return logLines, errs
+}
+
+// Sends the request for multiple log lines, and emits them on the channel as they are decoded.
+func (api *createLogLineAPI) streamMany(
+	ctx context.Context, instruction string, remix []string, n int, logLines chan<- string,
+) error {
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLinesStream")
+	span.setAttributes(
+		attribute.Int("gen_api.instruction.length", len(instruction)),
+		attribute.Int("gen_api.candidates", n),
+	)
+
+	status, err := api.readMany(ctx, instruction, remix, n, logLines)
+	span.end(status, err)
+
+	return err
+}
+
+func (api *createLogLineAPI) readMany(
+	ctx context.Context, instruction string, remix []string, n int, logLines chan<- string,
+) (int, error) {
+	if err := validateInstruction(instruction); err != nil {
+		return 0, err
+	}
+
+	if n < 1 {
+		return 0, fmt.Errorf("%w: at least 1 log line must be requested, got %d", ErrInvalidArgument, n)
+	}
+
+	if status, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return status, err
+	}
+
+	remix, err := api.cfg.normalizeRemix(remix)
+	if err != nil {
+		return 0, err
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	res, err := api.cfg.doRaw(
+		ctx, http.MethodPut, api.cfg.apiPath("/log-lines"),
+		createLogLineRequest{Instruction: instruction, Remix: remix, N: n},
+		editors...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if err := gatewayutils.EnsureStatus(res, http.StatusOK); err != nil {
+		return res.StatusCode, api.cfg.responseError(res, err)
+	}
+
+	api.cfg.notifyResponseHeader(res)
+
+	if err := decodeLogLines(ctx, res.Body, logLines); err != nil {
+		// Reading fails when the context is done: report the cause rather than the read error.
+		if ctx.Err() != nil {
+			return res.StatusCode, ctx.Err()
+		}
+
+		return res.StatusCode, fmt.Errorf("decode log lines: %w", err)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Decodes the log lines of a create response one by one, and emits each of them on the channel as soon as it is
+// parsed. The rest of the response is never held in memory.
+func decodeLogLines(ctx context.Context, body io.Reader, logLines chan<- string) error {
+	decoder := json.NewDecoder(body)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if key != "logLines" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return err
+		}
+
+		for decoder.More() {
+			var logLine string
+			if err := decoder.Decode(&logLine); err != nil {
+				return err
+			}
+
+			select {
+			case logLines <- logLine:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads the next token of the decoder, and checks that it is the given delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+
+	return nil
+}
@@ -0,0 +1,466 @@
+package v1
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// An error returned by a mocked response. In YAML, it is described by its message.
+type mockError struct {
+	error
+}
+
+func (err *mockError) UnmarshalYAML(value *yaml.Node) error {
+	var message string
+	if decodeErr := value.Decode(&message); decodeErr != nil {
+		return decodeErr
+	}
+
+	err.error = errors.New(message)
+
+	return nil
+}
+
+// A mocked response of the CreateLogLineAPI.
+type createLogLineMock struct {
+	Result string    `yaml:"result,omitempty"`
+	Status int       `yaml:"status,omitempty"`
+	Err    mockError `yaml:"error,omitempty"`
+	// Simulated duration of the call, written as a Go duration (for example "150ms").
+	Latency time.Duration `yaml:"latency,omitempty"`
+}
+
+// A mocked response of the ValidateLogLineAPI.
+type validateLogLineMock struct {
+	Status int       `yaml:"status,omitempty"`
+	Err    mockError `yaml:"error,omitempty"`
+	// Simulated duration of the call, written as a Go duration (for example "150ms").
+	Latency time.Duration `yaml:"latency,omitempty"`
+}
+
+// A mocked response of a method of the log line APIs that returns a single result, such as
+// CreateLogLineAPI.CreateVariations.
+type resultMock[Result any] struct {
+	Result Result    `yaml:"result,omitempty"`
+	Status int       `yaml:"status,omitempty"`
+	Err    mockError `yaml:"error,omitempty"`
+	// Simulated duration of the call, written as a Go duration (for example "150ms").
+	Latency time.Duration `yaml:"latency,omitempty"`
+}
+
+// MockUseCase names a scenario of the Mock methods. Scenarios registered at runtime, or read from a custom source, can
+// use any name.
+type MockUseCase string
+
+// Use cases of the mocks embedded in this package.
+const (
+	// A successful call. This is the default use case.
+	MockSuccess MockUseCase = "success"
+	// The service rejected the request with a 400 status.
+	MockBadRequest MockUseCase = "badRequest"
+	// The service failed with a 500 status.
+	MockInternal MockUseCase = "internal"
+	// The log line is invalid (422 status). Only available to the methods that reject invalid log lines:
+	// ValidateLogLineAPI.Mock, CreateLogLineAPI.MockRefine, CreateLogLineAPI.MockGenerateTitle and
+	// AnalyzeLogLineAPI.MockScore.
+	MockInvalid MockUseCase = "invalid"
+)
+
+// Mocked responses of the log line APIs, indexed by use case.
+type logLineMocks struct {
+	Create   map[string]createLogLineMock   `yaml:"create,omitempty"`
+	Validate map[string]validateLogLineMock `yaml:"validate,omitempty"`
+	// Scenarios of CreateLogLineAPI.MockVariations.
+	Variations map[string]resultMock[[]string] `yaml:"variations,omitempty"`
+	// Scenarios of CreateLogLineAPI.MockRefine.
+	Refine map[string]resultMock[string] `yaml:"refine,omitempty"`
+	// Scenarios of AnalyzeLogLineAPI.MockScore.
+	Score map[string]resultMock[float64] `yaml:"score,omitempty"`
+	// Scenarios of AnalyzeLogLineAPI.MockDetectLanguage.
+	DetectLanguage map[string]resultMock[detectLanguageResponse] `yaml:"detectLanguage,omitempty"`
+	// Scenarios of CreateLogLineAPI.MockModerate.
+	Moderate map[string]resultMock[ModerationResult] `yaml:"moderate,omitempty"`
+	// Scenarios of AnalyzeLogLineAPI.MockExtractKeywords.
+	Keywords map[string]resultMock[[]string] `yaml:"keywords,omitempty"`
+	// Scenarios of CreateLogLineAPI.MockGenerateTitle.
+	Title map[string]resultMock[string] `yaml:"title,omitempty"`
+	// Scenarios of CreateLogLineAPI.MockTranslate.
+	Translate map[string]resultMock[string] `yaml:"translate,omitempty"`
+}
+
+// WithMockFallback makes calls return the mocked response of the given use case, when the Gen-API service cannot be
+// reached (the error wraps gatewayutils.ErrUnavailable). This is meant for local development, where the service
+// may not be running. A warning is logged each time the fallback is used.
+//
+// Only connectivity failures trigger the fallback: responses with an error status are returned as usual. It applies
+// to the Call and CallWithOptions methods of CreateLogLineAPI, and to the Call method of ValidateLogLineAPI.
+func WithMockFallback(useCase MockUseCase) Option {
+	return func(cfg *config) {
+		cfg.mockFallback = useCase
+	}
+}
+
+// Tells whether a call that failed with err should return a mocked response instead.
+func (cfg *config) shouldFallbackToMock(ctx context.Context, operation string, err error) bool {
+	if cfg.mockFallback == "" || ctx.Err() != nil || !errors.Is(err, gatewayutils.ErrUnavailable) {
+		return false
+	}
+
+	cfg.warnLogger().WarnContext(
+		ctx, "gen-api is unavailable, falling back to a mocked response",
+		slog.String("operation", operation), slog.String("useCase", string(cfg.mockFallback)), slog.Any("error", err),
+	)
+
+	return true
+}
+
+//go:embed log-line-mocks.yaml
+var mocksFile []byte
+
+// Loads the mocked responses shipped with the package, on first use. Importing the package must never fail, so any
+// error in the embedded file is reported by the Mock methods instead.
+var embeddedMocks = sync.OnceValues(func() (*logLineMocks, error) {
+	embedded := new(logLineMocks)
+	if err := yaml.Unmarshal(mocksFile, embedded); err != nil {
+		return nil, fmt.Errorf("load embedded mocks: %w", err)
+	}
+
+	return embedded, nil
+})
+
+// Reads mocked responses from a YAML source, using the same format as the embedded mocks. The scenarios read are
+// merged over the embedded ones, so a use case can be replaced by declaring it again.
+func readMocks(source io.Reader) (*logLineMocks, error) {
+	defaults, err := embeddedMocks()
+	if err != nil {
+		return nil, err
+	}
+
+	custom := new(logLineMocks)
+	if err := yaml.NewDecoder(source).Decode(custom); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return &logLineMocks{
+		Create:         mergeMocks(defaults.Create, custom.Create),
+		Validate:       mergeMocks(defaults.Validate, custom.Validate),
+		Variations:     mergeMocks(defaults.Variations, custom.Variations),
+		Refine:         mergeMocks(defaults.Refine, custom.Refine),
+		Score:          mergeMocks(defaults.Score, custom.Score),
+		DetectLanguage: mergeMocks(defaults.DetectLanguage, custom.DetectLanguage),
+		Moderate:       mergeMocks(defaults.Moderate, custom.Moderate),
+		Keywords:       mergeMocks(defaults.Keywords, custom.Keywords),
+		Title:          mergeMocks(defaults.Title, custom.Title),
+		Translate:      mergeMocks(defaults.Translate, custom.Translate),
+	}, nil
+}
+
+// Returns the scenarios of a section of the mocks, with the custom ones replacing the default ones of the same use
+// case.
+func mergeMocks[Mock any](defaults, custom map[string]Mock) map[string]Mock {
+	merged := make(map[string]Mock, len(defaults)+len(custom))
+	maps.Copy(merged, defaults)
+	maps.Copy(merged, custom)
+
+	return merged
+}
+
+// Returns the mocked response of a section of the mocks for the given use case, from the custom scenarios of an
+// instance (if not nil), or the embedded ones.
+func findMock[Mock any](
+	custom *logLineMocks, section func(mocks *logLineMocks) map[string]Mock, useCase string,
+) (Mock, error) {
+	if custom == nil {
+		defaults, err := embeddedMocks()
+		if err != nil {
+			var zero Mock
+			return zero, err
+		}
+
+		custom = defaults
+	}
+
+	mocked, ok := section(custom)[useCase]
+	if !ok {
+		return mocked, fmt.Errorf("unknown use case: %s", useCase)
+	}
+
+	return mocked, nil
+}
+
+// Returns the mocked response of a method that returns a single result, after waiting for its latency. Like for a
+// real call, the error of the context is returned right away if it is already done.
+func mockResult[Result any](
+	ctx context.Context, cfg *config, custom *logLineMocks,
+	section func(mocks *logLineMocks) map[string]resultMock[Result], useCase MockUseCase,
+) (Result, int, error) {
+	var zero Result
+
+	if err := ctx.Err(); err != nil {
+		return zero, 0, err
+	}
+
+	if useCase == "" {
+		useCase = MockSuccess
+	}
+
+	mocked, err := findMock(custom, section, string(useCase))
+	if err != nil {
+		return zero, 0, err
+	}
+
+	// Simulate a slow server, that the caller may give up on.
+	if mocked.Latency > 0 {
+		if err := cfg.sleep(ctx, mocked.Latency); err != nil {
+			return zero, 0, err
+		}
+	}
+
+	return mocked.Result, mocked.Status, mocked.Err.error
+}
+
+// MockStep is one of the responses of a mocked sequence.
+type MockStep struct {
+	// The generated log line. Ignored by the validate API.
+	Result string
+	Status int
+	Err    error
+	// Simulated duration of the call.
+	Latency time.Duration
+}
+
+// An ordered list of mocked responses, returned one after the other.
+type mockSequence[Mock any] struct {
+	steps []Mock
+	// Index of the next step to return.
+	next int
+}
+
+// Returns the current step of the sequence, and moves to the next one. The last step is repeated once the sequence
+// is exhausted.
+func (sequence *mockSequence[Mock]) advance() Mock {
+	step := sequence.steps[sequence.next]
+	if sequence.next < len(sequence.steps)-1 {
+		sequence.next++
+	}
+
+	return step
+}
+
+// Scenarios registered at runtime, shared by the whole package.
+var registeredMocks struct {
+	sync.Mutex
+	create            map[string]createLogLineMock
+	validate          map[string]validateLogLineMock
+	createSequences   map[string]*mockSequence[createLogLineMock]
+	validateSequences map[string]*mockSequence[validateLogLineMock]
+}
+
+// RegisterCreateMock registers a scenario for the Mock method of CreateLogLineAPI. It is safe for concurrent use.
+//
+// The registration affects the whole package: every instance of CreateLogLineAPI returns this scenario for the
+// given use case, over its own. Use ResetMocks to remove registered scenarios, typically between tests.
+func RegisterCreateMock(useCase string, result string, status int, err error) {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	if registeredMocks.create == nil {
+		registeredMocks.create = make(map[string]createLogLineMock)
+	}
+
+	registeredMocks.create[useCase] = createLogLineMock{Result: result, Status: status, Err: mockError{err}}
+}
+
+// RegisterValidateMock registers a scenario for the Mock method of ValidateLogLineAPI. It is safe for concurrent use.
+//
+// The registration affects the whole package: every instance of ValidateLogLineAPI returns this scenario for the
+// given use case, over its own. Use ResetMocks to remove registered scenarios, typically between tests.
+func RegisterValidateMock(useCase string, status int, err error) {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	if registeredMocks.validate == nil {
+		registeredMocks.validate = make(map[string]validateLogLineMock)
+	}
+
+	registeredMocks.validate[useCase] = validateLogLineMock{Status: status, Err: mockError{err}}
+}
+
+// RegisterCreateMockSequence registers a sequence of responses for the Mock method of CreateLogLineAPI. Each call
+// for the use case returns the next step of the sequence, and the last step is repeated once all of them have been
+// returned. It is safe for concurrent use.
+//
+// Sequences take precedence over every other scenario, and affect the whole package, like RegisterCreateMock. An
+// empty sequence unregisters the use case.
+func RegisterCreateMockSequence(useCase string, steps []MockStep) {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	if len(steps) == 0 {
+		delete(registeredMocks.createSequences, useCase)
+		return
+	}
+
+	if registeredMocks.createSequences == nil {
+		registeredMocks.createSequences = make(map[string]*mockSequence[createLogLineMock])
+	}
+
+	sequence := &mockSequence[createLogLineMock]{steps: make([]createLogLineMock, len(steps))}
+	for i, step := range steps {
+		sequence.steps[i] = createLogLineMock{
+			Result:  step.Result,
+			Status:  step.Status,
+			Err:     mockError{step.Err},
+			Latency: step.Latency,
+		}
+	}
+
+	registeredMocks.createSequences[useCase] = sequence
+}
+
+// RegisterValidateMockSequence registers a sequence of responses for the Mock method of ValidateLogLineAPI. Each call
+// for the use case returns the next step of the sequence, and the last step is repeated once all of them have been
+// returned. It is safe for concurrent use.
+//
+// Sequences take precedence over every other scenario, and affect the whole package, like RegisterValidateMock. An
+// empty sequence unregisters the use case.
+func RegisterValidateMockSequence(useCase string, steps []MockStep) {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	if len(steps) == 0 {
+		delete(registeredMocks.validateSequences, useCase)
+		return
+	}
+
+	if registeredMocks.validateSequences == nil {
+		registeredMocks.validateSequences = make(map[string]*mockSequence[validateLogLineMock])
+	}
+
+	sequence := &mockSequence[validateLogLineMock]{steps: make([]validateLogLineMock, len(steps))}
+	for i, step := range steps {
+		sequence.steps[i] = validateLogLineMock{
+			Status:  step.Status,
+			Err:     mockError{step.Err},
+			Latency: step.Latency,
+		}
+	}
+
+	registeredMocks.validateSequences[useCase] = sequence
+}
+
+// ResetMockSequences rewinds every registered sequence, so the next call returns their first step again.
+func ResetMockSequences() {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	for _, sequence := range registeredMocks.createSequences {
+		sequence.next = 0
+	}
+	for _, sequence := range registeredMocks.validateSequences {
+		sequence.next = 0
+	}
+}
+
+// ResetMocks removes every scenario and sequence registered at runtime, restoring the ones embedded in the package.
+func ResetMocks() {
+	registeredMocks.Lock()
+	defer registeredMocks.Unlock()
+
+	registeredMocks.create = nil
+	registeredMocks.validate = nil
+	registeredMocks.createSequences = nil
+	registeredMocks.validateSequences = nil
+}
+
+// Returns the mocked response of CreateLogLineAPI for the given use case. Registered sequences and scenarios come
+// first, then the custom ones of the instance (if not nil), or the embedded ones.
+func findCreateLogLineMock(custom *logLineMocks, useCase string) (createLogLineMock, error) {
+	registeredMocks.Lock()
+	mocked, ok := registeredMocks.create[useCase]
+	if sequence, isSequence := registeredMocks.createSequences[useCase]; isSequence {
+		mocked, ok = sequence.advance(), true
+	}
+	registeredMocks.Unlock()
+
+	if ok {
+		return mocked, nil
+	}
+
+	return findMock(custom, func(mocks *logLineMocks) map[string]createLogLineMock { return mocks.Create }, useCase)
+}
+
+// Returns the mocked response of ValidateLogLineAPI for the given use case. Registered sequences and scenarios come
+// first, then the custom ones of the instance (if not nil), or the embedded ones.
+func findValidateLogLineMock(custom *logLineMocks, useCase string) (validateLogLineMock, error) {
+	registeredMocks.Lock()
+	mocked, ok := registeredMocks.validate[useCase]
+	if sequence, isSequence := registeredMocks.validateSequences[useCase]; isSequence {
+		mocked, ok = sequence.advance(), true
+	}
+	registeredMocks.Unlock()
+
+	if ok {
+		return mocked, nil
+	}
+
+	return findMock(custom, func(mocks *logLineMocks) map[string]validateLogLineMock { return mocks.Validate }, useCase)
+}
+
+// CreateMockUseCases returns the use cases accepted by the Mock method of CreateLogLineAPI, sorted: the embedded
+// ones, and the ones registered at runtime. The custom scenarios of an instance, read by NewCreateLogLineAPIWithMocks,
+// are not included.
+func CreateMockUseCases() []string {
+	useCases := make(map[string]struct{})
+
+	if defaults, err := embeddedMocks(); err == nil {
+		for useCase := range defaults.Create {
+			useCases[useCase] = struct{}{}
+		}
+	}
+
+	registeredMocks.Lock()
+	for useCase := range registeredMocks.create {
+		useCases[useCase] = struct{}{}
+	}
+	for useCase := range registeredMocks.createSequences {
+		useCases[useCase] = struct{}{}
+	}
+	registeredMocks.Unlock()
+
+	return slices.Sorted(maps.Keys(useCases))
+}
+
+// ValidateMockUseCases returns the use cases accepted by the Mock method of ValidateLogLineAPI, sorted: the embedded
+// ones, and the ones registered at runtime. The custom scenarios of an instance, read by
+// NewValidateLogLineAPIWithMocks, are not included.
+func ValidateMockUseCases() []string {
+	useCases := make(map[string]struct{})
+
+	if defaults, err := embeddedMocks(); err == nil {
+		for useCase := range defaults.Validate {
+			useCases[useCase] = struct{}{}
+		}
+	}
+
+	registeredMocks.Lock()
+	for useCase := range registeredMocks.validate {
+		useCases[useCase] = struct{}{}
+	}
+	for useCase := range registeredMocks.validateSequences {
+		useCases[useCase] = struct{}{}
+	}
+	registeredMocks.Unlock()
+
+	return slices.Sorted(maps.Keys(useCases))
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"testing"
+)
+
+func TestEmbeddedMocksParse(t *testing.T) {
+	if _, err := embeddedMocks(); err != nil {
+		t.Fatalf("failed to load the embedded mocks: %v", err)
+	}
+
+	// A misspelled section or field would be silently ignored by the loader.
+	decoder := yaml.NewDecoder(bytes.NewReader(mocksFile))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(new(logLineMocks)); err != nil {
+		t.Fatalf("unexpected content in the embedded mocks: %v", err)
+	}
+}
+
+func TestMockUseCasesResolve(t *testing.T) {
+	create := NewCreateLogLineAPI("http://localhost")
+	validate := NewValidateLogLineAPI("http://localhost")
+	analyze := NewAnalyzeLogLineAPI("http://localhost")
+
+	// Calls a Mock method, and returns the status and error of the mocked response.
+	type mockMethod func(ctx context.Context, useCase MockUseCase) (int, error)
+
+	methods := []struct {
+		name string
+		call mockMethod
+		// Whether the method supports MockInvalid.
+		invalid bool
+	}{
+		{name: "Create", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.Mock(ctx, useCase)
+			return status, err
+		}},
+		{name: "Validate", invalid: true, call: validate.Mock},
+		{name: "Variations", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.MockVariations(ctx, useCase)
+			return status, err
+		}},
+		{name: "Refine", invalid: true, call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.MockRefine(ctx, useCase)
+			return status, err
+		}},
+		{name: "GenerateTitle", invalid: true, call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.MockGenerateTitle(ctx, useCase)
+			return status, err
+		}},
+		{name: "Translate", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.MockTranslate(ctx, useCase)
+			return status, err
+		}},
+		{name: "Moderate", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := create.MockModerate(ctx, useCase)
+			return status, err
+		}},
+		{name: "Score", invalid: true, call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := analyze.MockScore(ctx, useCase)
+			return status, err
+		}},
+		{name: "DetectLanguage", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, _, status, err := analyze.MockDetectLanguage(ctx, useCase)
+			return status, err
+		}},
+		{name: "ExtractKeywords", call: func(ctx context.Context, useCase MockUseCase) (int, error) {
+			_, status, err := analyze.MockExtractKeywords(ctx, useCase)
+			return status, err
+		}},
+	}
+
+	useCases := []struct {
+		useCase MockUseCase
+		status  int
+	}{
+		{useCase: MockSuccess},
+		{useCase: MockBadRequest, status: http.StatusBadRequest},
+		{useCase: MockInternal, status: http.StatusInternalServerError},
+		{useCase: MockInvalid, status: http.StatusUnprocessableEntity},
+	}
+
+	for _, method := range methods {
+		for _, useCase := range useCases {
+			if useCase.useCase == MockInvalid && !method.invalid {
+				continue
+			}
+
+			t.Run(method.name+"/"+string(useCase.useCase), func(t *testing.T) {
+				status, err := method.call(context.Background(), useCase.useCase)
+
+				if useCase.useCase == MockSuccess {
+					if err != nil || status < 200 || status >= 300 {
+						t.Errorf("expected a successful response, got %d: %v", status, err)
+					}
+
+					return
+				}
+
+				if err == nil || status != useCase.status {
+					t.Errorf("expected an error with status %d, got %d: %v", useCase.status, status, err)
+				}
+			})
+		}
+	}
+}
+
+func TestMockUnknownUseCase(t *testing.T) {
+	if _, _, err := NewCreateLogLineAPI("http://localhost").Mock(context.Background(), "unknown"); err == nil {
+		t.Error("expected an error for an unknown use case")
+	}
+}
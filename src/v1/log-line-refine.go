@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+)
+
+// Body of a rewrite request.
+type refineLogLineRequest struct {
+	LogLine     string `json:"logLine"`
+	Instruction string `json:"instruction"`
+}
+
+func (api *createLogLineAPI) Refine(ctx context.Context, logLine string, instruction string) (string, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return "", 0, err
+	}
+
+	if err := validateInstruction(instruction); err != nil {
+		return "", 0, err
+	}
+
+	if status, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return "", status, err
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.RefineLogLine")
+	span.setAttributes(
+		attribute.Int("gen_api.log_line.length", len(logLine)),
+		attribute.Int("gen_api.instruction.length", len(instruction)),
+	)
+
+	// A 422 status, when the draft is not a log line, is turned into ErrInvalidLogLine by the shared error handling.
+	responseBody, status, err := doJSON[refineLogLineRequest, createLogLineResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/rewrite"),
+		refineLogLineRequest{LogLine: logLine, Instruction: instruction},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody.LogLine, status, err
+}
+
+func (api *createLogLineAPI) MockRefine(ctx context.Context, useCase MockUseCase) (string, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, refineMocks, useCase)
+}
+
+// Returns the scenarios of MockRefine.
+func refineMocks(mocks *logLineMocks) map[string]resultMock[string] {
+	return mocks.Refine
+}
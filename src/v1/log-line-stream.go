@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrNotStreaming is returned when a streaming call gets a response that is not an event stream.
+var ErrNotStreaming = errors.New("response is not an event stream")
+
+// Data sent by the server to signal the end of a stream of events.
+const streamDoneData = "[DONE]"
+
+func (api *createLogLineAPI) CreateStream(
+	ctx context.Context, instruction string, remix []string,
+) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	// Buffered, so the goroutine never blocks on reporting its single error.
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(tokens)
+
+		if err := api.stream(ctx, instruction, remix, tokens); err != nil {
+			errs <- err
+		}
+	}()
+
+	return tokens, errs
+}
+
+// Sends the streaming request, and emits the tokens of the response on the channel until the stream ends.
+func (api *createLogLineAPI) stream(
+	ctx context.Context, instruction string, remix []string, tokens chan<- string,
+) error {
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLineStream")
+
+	status, err := api.readStream(ctx, instruction, remix, tokens)
+	span.end(status, err)
+
+	return err
+}
+
+func (api *createLogLineAPI) readStream(
+	ctx context.Context, instruction string, remix []string, tokens chan<- string,
+) (int, error) {
+	done, err := api.cfg.calls.start()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	if err := api.cfg.checkServerVersion(ctx); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := api.cfg.withTimeout(ctx)
+	defer cancel()
+
+	ctx = api.cfg.withRequestID(ctx)
+
+	if err := validateInstruction(instruction); err != nil {
+		return 0, err
+	}
+
+	if status, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return status, err
+	}
+
+	remix, err = api.cfg.normalizeRemix(remix)
+	if err != nil {
+		return 0, err
+	}
+
+	jsonBody, err := api.cfg.codec.Marshal(createLogLineRequest{Instruction: instruction, Remix: remix, N: 1})
+	if err != nil {
+		return 0, err
+	}
+
+	editors := []requestEditor{withHeader("Accept", "text/event-stream")}
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	res, err := api.cfg.sendBody(ctx, http.MethodPut, api.cfg.apiPath("/log-lines"), jsonBody, editors...)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if err := gatewayutils.EnsureStatus(res, http.StatusOK); err != nil {
+		return res.StatusCode, api.cfg.responseError(res, err)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return res.StatusCode, fmt.Errorf("%w: got content type %q", ErrNotStreaming, mediaType)
+	}
+
+	api.cfg.notifyResponseHeader(res)
+
+	return res.StatusCode, readEvents(ctx, res.Body, tokens)
+}
+
+// Reads a stream of server-sent events, and emits the data of each event on the channel. An event named "error"
+// aborts the stream with its data as the error message.
+func readEvents(ctx context.Context, body io.Reader, tokens chan<- string) error {
+	scanner := bufio.NewScanner(body)
+
+	var (
+		event string
+		data  []string
+	)
+
+	// Dispatches the event read so far. It returns true once the stream is over.
+	dispatch := func() (bool, error) {
+		defer func() {
+			event, data = "", nil
+		}()
+
+		if len(data) == 0 {
+			return false, nil
+		}
+
+		payload := strings.Join(data, "\n")
+
+		if event == "error" {
+			return true, errors.New(payload)
+		}
+
+		if payload == streamDoneData {
+			return true, nil
+		}
+
+		select {
+		case tokens <- payload:
+			return false, nil
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// An empty line marks the end of an event.
+		if line == "" {
+			if done, err := dispatch(); done || err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Comments, used as keep-alive.
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// Reading fails when the context is done: report the cause rather than the read error.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	// The stream may end without a trailing empty line.
+	_, err := dispatch()
+
+	return err
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+)
+
+// Body of a title request.
+type generateTitleRequest struct {
+	LogLine string `json:"logLine"`
+}
+
+// Body of a title response.
+type generateTitleResponse struct {
+	Title string `json:"title"`
+}
+
+func (api *createLogLineAPI) GenerateTitle(ctx context.Context, logLine string) (string, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return "", 0, err
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.GenerateLogLineTitle")
+	span.setAttributes(attribute.Int("gen_api.log_line.length", len(logLine)))
+
+	// A 422 status, when the input is not a log line, is turned into ErrInvalidLogLine by the shared error handling.
+	responseBody, status, err := doJSON[generateTitleRequest, generateTitleResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/title"),
+		generateTitleRequest{LogLine: logLine},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody.Title, status, err
+}
+
+func (api *createLogLineAPI) MockGenerateTitle(ctx context.Context, useCase MockUseCase) (string, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, titleMocks, useCase)
+}
+
+// Returns the scenarios of MockGenerateTitle.
+func titleMocks(mocks *logLineMocks) map[string]resultMock[string] {
+	return mocks.Title
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+)
+
+// Body of a translation request.
+type translateLogLineRequest struct {
+	LogLine string `json:"logLine"`
+	// BCP 47 tag of the language to translate the log line to.
+	Lang string `json:"lang"`
+}
+
+func (api *createLogLineAPI) Translate(ctx context.Context, logLine, targetLang string) (string, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return "", 0, err
+	}
+
+	targetLang, err := normalizeLang(targetLang)
+	if err != nil {
+		return "", 0, err
+	}
+
+	editors := []requestEditor{withHeader("Accept-Language", targetLang)}
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.TranslateLogLine")
+	span.setAttributes(
+		attribute.Int("gen_api.log_line.length", len(logLine)),
+		attribute.String("gen_api.lang", targetLang),
+	)
+
+	responseBody, status, err := doJSON[translateLogLineRequest, createLogLineResponse](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines/translate"),
+		translateLogLineRequest{LogLine: logLine, Lang: targetLang},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody.LogLine, status, err
+}
+
+func (api *createLogLineAPI) MockTranslate(ctx context.Context, useCase MockUseCase) (string, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, translateMocks, useCase)
+}
+
+// Returns the scenarios of MockTranslate.
+func translateMocks(mocks *logLineMocks) map[string]resultMock[string] {
+	return mocks.Translate
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	testCases := []struct {
+		name string
+		lang string
+		// Canonical form of the tag, as sent to the server.
+		expected string
+	}{
+		{name: "French", lang: "fr", expected: "fr"},
+		{name: "Region", lang: "es-mx", expected: "es-MX"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			logLine, _, err := NewCreateLogLineAPI(server.URL).Translate(context.Background(), "a log line", testCase.lang)
+			if err != nil {
+				t.Fatalf("failed to translate: %v", err)
+			}
+
+			if logLine != "a fake translated log line" {
+				t.Errorf("expected the translated log line, got %q", logLine)
+			}
+
+			var body translateLogLineRequest
+			if err := json.Unmarshal([]byte(server.Requests()[0].Body), &body); err != nil {
+				t.Fatalf("failed to decode the request: %v", err)
+			}
+
+			if body.Lang != testCase.expected || body.LogLine != "a log line" {
+				t.Errorf("expected a translation of %q to %q, got %+v", "a log line", testCase.expected, body)
+			}
+		})
+	}
+}
+
+func TestTranslateInvalidTag(t *testing.T) {
+	testCases := []struct {
+		name string
+		lang string
+	}{
+		{name: "Empty", lang: ""},
+		{name: "Malformed", lang: "not a tag"},
+		{name: "TooLong", lang: "abcdefghi"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			_, status, err := NewCreateLogLineAPI(server.URL).Translate(context.Background(), "a log line", testCase.lang)
+			if !errors.Is(err, ErrInvalidArgument) || status != 0 {
+				t.Errorf("expected ErrInvalidArgument, got %d: %v", status, err)
+			}
+
+			if requests := server.Requests(); len(requests) > 0 {
+				t.Errorf("expected no request to be sent, got %d", len(requests))
+			}
+		})
+	}
+}
+
+func TestMockTranslate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		useCase MockUseCase
+		// A word of the expected translation.
+		expected string
+	}{
+		{name: "French", useCase: MockSuccess, expected: "la Terre"},
+		{name: "Spanish", useCase: "spanish", expected: "la Tierra"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logLine, _, err := NewCreateLogLineAPI("http://localhost").MockTranslate(context.Background(), testCase.useCase)
+			if err != nil {
+				t.Fatalf("failed to mock the translation: %v", err)
+			}
+
+			if !strings.Contains(logLine, testCase.expected) {
+				t.Errorf("expected the translation to contain %q, got %q", testCase.expected, logLine)
+			}
+		})
+	}
+}
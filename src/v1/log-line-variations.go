@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+)
+
+// Body of a variations request.
+type createVariationsRequest struct {
+	LogLine string `json:"logLine"`
+	// Number of variations to generate.
+	N int `json:"n"`
+}
+
+func (api *createLogLineAPI) CreateVariations(
+	ctx context.Context, logLine string, count int,
+) ([]string, int, error) {
+	if err := validateLogLine(logLine); err != nil {
+		return nil, 0, err
+	}
+
+	if count < 1 {
+		return nil, 0, fmt.Errorf("%w: at least 1 variation must be requested, got %d", ErrInvalidArgument, count)
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLineVariations")
+	span.setAttributes(
+		attribute.Int("gen_api.log_line.length", len(logLine)),
+		attribute.Int("gen_api.candidates", count),
+	)
+
+	responseBody, status, err := doJSON[createVariationsRequest, createLogLinesResponse](
+		ctx, api.cfg, http.MethodPut, api.cfg.apiPath("/log-lines/variations"),
+		createVariationsRequest{LogLine: logLine, N: count},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody.LogLines, status, err
+}
+
+func (api *createLogLineAPI) MockVariations(ctx context.Context, useCase MockUseCase) ([]string, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, variationsMocks, useCase)
+}
+
+// Returns the scenarios of MockVariations.
+func variationsMocks(mocks *logLineMocks) map[string]resultMock[[]string] {
+	return mocks.Variations
+}
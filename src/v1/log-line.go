@@ -1,42 +1,123 @@
 package v1
 
 import (
-	"bytes"
 	"context"
-	_ "embed"
-	"encoding/json"
 	"errors"
 	"fmt"
-	gatewayutils "github.com/a-novel/gateway-utils"
-	"gopkg.in/yaml.v3"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/text/language"
+	"io"
 	"net/http"
-	"net/url"
+	"strings"
+	"time"
 )
 
 var (
 	ErrInvalidLogLine = errors.New("invalid log line")
+
+	// ErrInvalidArgument is wrapped by the errors returned when a call is given an invalid argument. Such calls fail
+	// without sending any request.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrEmptyInstruction is returned when a create call is given an instruction with no text.
+	ErrEmptyInstruction = fmt.Errorf("%w: empty instruction", ErrInvalidArgument)
+	// ErrEmptyLogLine is returned when a call that transforms a log line is given a log line with no text.
+	ErrEmptyLogLine = fmt.Errorf("%w: empty log line", ErrInvalidArgument)
 )
 
-var mocks struct {
-	Create map[string]struct {
-		Result string `yaml:"result,omitempty"`
-		Status int    `yaml:"status,omitempty"`
-		Err    error  `yaml:"err,omitempty"`
-	} `yaml:"create,omitempty"`
-	Validate map[string]struct {
-		Status int   `yaml:"status,omitempty"`
-		Err    error `yaml:"err,omitempty"`
-	} `yaml:"validate,omitempty"`
+// Checks that an instruction has some text.
+func validateInstruction(instruction string) error {
+	if strings.TrimSpace(instruction) == "" {
+		return ErrEmptyInstruction
+	}
+
+	return nil
 }
 
-//go:embed log-line-mocks.yaml
-var mocksFile []byte
+// Checks that a log line to transform has some text.
+func validateLogLine(logLine string) error {
+	if strings.TrimSpace(logLine) == "" {
+		return ErrEmptyLogLine
+	}
+
+	return nil
+}
 
-// Load mocked data.
-func init() {
-	if err := yaml.Unmarshal(mocksFile, &mocks); err != nil {
-		panic(err)
+// Returns the canonical form of a BCP 47 language tag, or an error wrapping ErrInvalidArgument if it is not valid.
+func normalizeLang(lang string) (string, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid language %q: %w", ErrInvalidArgument, lang, err)
 	}
+
+	return tag.String(), nil
+}
+
+// Body of a create request.
+type createLogLineRequest struct {
+	Instruction string   `json:"instruction"`
+	Remix       []string `json:"remix"`
+	// Number of log lines to generate.
+	N int `json:"n"`
+
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxLength   int     `json:"maxLength,omitempty"`
+	Seed        int64   `json:"seed,omitempty"`
+	// BCP 47 tag of the language to generate the log line in.
+	Lang string `json:"lang,omitempty"`
+}
+
+// Body of a create response.
+type createLogLineResponse struct {
+	LogLine string `json:"logLine"`
+	// Name of the model that generated the log line.
+	Model string             `json:"model,omitempty"`
+	Usage createLogLineUsage `json:"usage"`
+}
+
+// Tokens consumed by a generation.
+type createLogLineUsage struct {
+	PromptTokens     int `json:"promptTokens,omitempty"`
+	CompletionTokens int `json:"completionTokens,omitempty"`
+}
+
+// Body of a create response, when multiple log lines are requested.
+type createLogLinesResponse struct {
+	LogLines []string `json:"logLines"`
+}
+
+// Body of a validate request.
+type validateLogLineRequest struct {
+	LogLine string `json:"logLine"`
+}
+
+// CreateOptions tunes the generation of a log line. Zero values are not sent, so the server defaults apply.
+type CreateOptions struct {
+	// Controls the randomness of the generation. Higher values give more creative results.
+	Temperature float64
+	// Maximum length of the generated log line.
+	MaxLength int
+	// Makes the generation reproducible: the same instructions, options and seed give the same log line.
+	Seed int64
+	// Language to generate the log line in, as a BCP 47 tag (for example "fr" or "es-MX"). The server default is used
+	// if empty.
+	Lang string
+}
+
+// CreateMeta describes how a log line was generated, so the cost of the generation can be attributed.
+type CreateMeta struct {
+	// Name of the model that generated the log line.
+	Model string
+	// Number of tokens in the prompt sent to the model.
+	PromptTokens int
+	// Number of tokens generated by the model.
+	CompletionTokens int
+	// ID of the request, as sent back by the server in the X-Request-ID header. It is the ID sent by the client if the
+	// server did not send one back. See WithRequestID.
+	RequestID string
+	// Identifies the generated log line, to make later calls conditional with WithIfNoneMatch. It is empty if the
+	// server did not send an ETag header.
+	ETag string
 }
 
 // CreateLogLineAPI sends a request to create a new log line from instructions.
@@ -44,73 +125,338 @@ type CreateLogLineAPI interface {
 	// Call executes the request. It returns the generated log line, along with the status of the response and error,
 	// if any.
 	//
-	// In case the API returns a non-200 status, a utils.StatusError will be thrown.
+	// The remix entries are normalized before being sent: they are trimmed, and the empty or duplicate ones are
+	// dropped. This applies to every create method.
+	//
+	// In case the API returns a non-200 status, a *StatusError will be thrown. A 429 status also comes with a
+	// RateLimitedError, telling how long to wait before trying again.
 	Call(ctx context.Context, instruction string, remix []string) (string, int, error)
+	// CallWithOptions works like Call, with additional parameters to tune the generation.
+	CallWithOptions(ctx context.Context, instruction string, remix []string, opts CreateOptions) (string, int, error)
+	// CreateWithMeta works like Call, and also returns the metadata of the generation. Fields of the metadata not
+	// provided by the server are left empty.
+	CreateWithMeta(ctx context.Context, instruction string, remix []string) (string, CreateMeta, int, error)
+	// CallRaw works like Call, but returns the response of the service as-is, whatever its status, so its headers,
+	// trailers and body can be read directly. An error is only returned if no response was received.
+	//
+	// The body of the response is left unread: the caller must close it.
+	CallRaw(ctx context.Context, instruction string, remix []string) (*http.Response, error)
+	// CreateMany works like Call, but generates n candidate log lines from the same instructions, so the user can
+	// pick one. n must be at least 1.
+	CreateMany(ctx context.Context, instruction string, remix []string, n int) ([]string, int, error)
+	// CreateManyStream works like CreateMany, but emits each log line on the first channel as soon as it is decoded
+	// from the response, instead of buffering the whole list. This bounds the memory used when n is large. The
+	// response is always decoded as JSON, regardless of WithCodec.
+	//
+	// The second channel receives at most one error, then is closed. Both channels are closed once the response is
+	// read, or the context is done.
+	CreateManyStream(ctx context.Context, instruction string, remix []string, n int) (<-chan string, <-chan error)
+	// CreateVariations generates count alternate phrasings of an existing log line. count must be at least 1.
+	CreateVariations(ctx context.Context, logLine string, count int) ([]string, int, error)
+	// Refine rewrites an existing log line, following the given instruction, and returns the improved log line. A 422
+	// status, along with ErrInvalidLogLine, is returned if the draft is not a log line.
+	Refine(ctx context.Context, logLine string, instruction string) (string, int, error)
+	// CreateStream works like Call, but streams the log line as it is generated, using server-sent events. Partial
+	// tokens are emitted on the first channel as soon as they arrive, and the channel is closed once the stream ends.
+	//
+	// The second channel receives at most one error, then is closed. An ErrNotStreaming error is returned if the
+	// server does not answer with an event stream. Cancelling the context aborts the stream, and closes both
+	// channels.
+	CreateStream(ctx context.Context, instruction string, remix []string) (<-chan string, <-chan error)
+	// CreateBatch generates a log line for each request, sending at most concurrency requests at a time. The results
+	// are returned in the same order as the requests.
+	//
+	// Once the context is done, no new request is sent, and the remaining results are skipped, with the error of the
+	// context. When the context has a deadline, the time left is shared between the requests (see FairBatchBudget).
+	CreateBatch(ctx context.Context, reqs []CreateRequest, concurrency int) []CreateResult
+	// CreateBatchWithOptions works like CreateBatch, with additional parameters to tune the processing of the batch.
+	CreateBatchWithOptions(ctx context.Context, reqs []CreateRequest, opts BatchOptions) []CreateResult
+	// CreateAsyncJob starts the generation of a log line in the background, on the server, and returns the ID of the
+	// job right away. Use PollResult to retrieve the log line once generated. This suits large generations, that
+	// would otherwise keep a connection open for a long time.
+	CreateAsyncJob(ctx context.Context, instruction string, remix []string) (string, error)
+	// CreateAsyncJobWithCallback works like CreateAsyncJob, but the server also sends the result of the job to the
+	// given URL, once the job completes, so it does not have to be polled. Use ParseCallbackRequest to decode the
+	// request of the server. The callback URL must be an absolute HTTP(S) URL.
+	CreateAsyncJobWithCallback(ctx context.Context, instruction string, remix []string, callbackURL string) (string, error)
+	// ParseCallbackRequest works like the function of the same name, but decodes the request with the Codec of the
+	// client (see WithCodec), and limits its size as the responses of the service (see WithMaxResponseBytes).
+	ParseCallbackRequest(r *http.Request) (string, string, error)
+	// PollResult returns the state of a job started with CreateAsyncJob: the log line, once available, and the status
+	// of the job (JobPending, JobRunning, JobSucceeded or JobFailed), along with the status of the response.
+	//
+	// A failed job comes with an error wrapping ErrJobFailed.
+	PollResult(ctx context.Context, jobID string) (string, string, int, error)
+	// CreateAwait starts a job with CreateAsyncJob, and polls it until it completes, fails, or the context is done.
+	// The first poll happens after pollInterval, and the delay doubles after each poll, up to 30 seconds (or
+	// pollInterval, if longer). pollInterval must be positive.
+	CreateAwait(ctx context.Context, instruction string, remix []string, pollInterval time.Duration) (string, int, error)
+	// CreateAsync works like Call, but runs in the background. The result is delivered on the returned channel, that
+	// is closed right after. The result is buffered, so the call completes even if the caller stops reading.
+	CreateAsync(ctx context.Context, instruction string, remix []string) <-chan CreateResult
 	// Mock returns a mocked response, based on the chosen scenario.
-	Mock(ctx context.Context, useCase string) (string, int, error)
+	//
+	// If the scenario has a latency, Mock waits for it before returning, or returns the error of the context if it is
+	// done first. Like for a real call, the error of the context is returned right away if it is already done.
+	Mock(ctx context.Context, useCase MockUseCase) (string, int, error)
+	// MockVariations returns a mocked response of CreateVariations, based on the chosen scenario. It behaves like
+	// Mock.
+	MockVariations(ctx context.Context, useCase MockUseCase) ([]string, int, error)
+	// GenerateTitle generates a short working title from a log line. A 422 status, along with ErrInvalidLogLine, is
+	// returned if the input is not a log line.
+	GenerateTitle(ctx context.Context, logLine string) (string, int, error)
+	// MockGenerateTitle returns a mocked response of GenerateTitle, based on the chosen scenario. It behaves like
+	// Mock.
+	MockGenerateTitle(ctx context.Context, useCase MockUseCase) (string, int, error)
+	// Translate translates a log line to the language of the given BCP 47 tag (for example "fr" or "es-MX"), while
+	// keeping its style. An error wrapping ErrInvalidArgument is returned, without sending any request, if the tag is
+	// not valid.
+	Translate(ctx context.Context, logLine, targetLang string) (string, int, error)
+	// MockTranslate returns a mocked response of Translate, based on the chosen scenario. It behaves like Mock. The
+	// embedded mocks translate to French by default, and to Spanish with the "spanish" use case.
+	MockTranslate(ctx context.Context, useCase MockUseCase) (string, int, error)
+	// Moderate checks whether a text contains disallowed content, using the moderation of the Gen-API service. See
+	// WithPreModeration to moderate the instruction of every create call.
+	Moderate(ctx context.Context, text string) (ModerationResult, int, error)
+	// MockModerate returns a mocked response of Moderate, based on the chosen scenario. It behaves like Mock. Besides
+	// the shared use cases, the embedded mocks flag the text with the "flagged" use case.
+	MockModerate(ctx context.Context, useCase MockUseCase) (ModerationResult, int, error)
+	// MockRefine returns a mocked response of Refine, based on the chosen scenario. It behaves like Mock.
+	MockRefine(ctx context.Context, useCase MockUseCase) (string, int, error)
 }
 
 // Implements the CreateLogLineAPI interface.
 type createLogLineAPI struct {
-	// The root URL for accessing the Gen-API service.
-	endpoint string
+	// Configuration of the client.
+	cfg *config
+	// Mocked responses. The embedded ones are used if nil.
+	mocks *logLineMocks
 }
 
 func (api *createLogLineAPI) Call(ctx context.Context, instruction string, remix []string) (string, int, error) {
-	path, err := url.JoinPath(api.endpoint, "/api/v1/log-lines")
-	if err != nil {
-		return "", 0, err
+	return api.CallWithOptions(ctx, instruction, remix, CreateOptions{})
+}
+
+func (api *createLogLineAPI) CallWithOptions(
+	ctx context.Context, instruction string, remix []string, opts CreateOptions,
+) (string, int, error) {
+	return api.callWithOptions(ctx, instruction, remix, opts, api.cfg.singleflight)
+}
+
+// Implements CallWithOptions. Concurrent identical calls share the same request if group is not nil.
+func (api *createLogLineAPI) callWithOptions(
+	ctx context.Context, instruction string, remix []string, opts CreateOptions, group *singleflight.Group,
+) (string, int, error) {
+	responseBody, status, err := api.createShared(ctx, instruction, remix, opts, group)
+	if api.cfg.shouldFallbackToMock(ctx, "CreateLogLine", err) {
+		return api.Mock(ctx, api.cfg.mockFallback)
 	}
 
-	jsonBody, err := json.Marshal(map[string]interface{}{
-		"instruction": instruction,
-		"remix":       remix,
-	})
-	if err != nil {
-		return "", 0, err
+	return responseBody.LogLine, status, err
+}
+
+func (api *createLogLineAPI) CreateWithMeta(
+	ctx context.Context, instruction string, remix []string,
+) (string, CreateMeta, int, error) {
+	// Set the ID beforehand, so it can be returned.
+	ctx = api.cfg.withRequestID(ctx)
+	requestID, _ := RequestIDFromContext(ctx)
+
+	ctx, header := captureResponseHeader(ctx)
+
+	responseBody, status, err := api.create(ctx, instruction, remix, CreateOptions{})
+	if api.cfg.shouldFallbackToMock(ctx, "CreateLogLine", err) {
+		logLine, status, err := api.Mock(ctx, api.cfg.mockFallback)
+		return logLine, CreateMeta{RequestID: requestID}, status, err
+	}
+
+	meta := CreateMeta{
+		Model:            responseBody.Model,
+		PromptTokens:     responseBody.Usage.PromptTokens,
+		CompletionTokens: responseBody.Usage.CompletionTokens,
+		RequestID:        requestID,
+		ETag:             header.Get("ETag"),
+	}
+
+	// The server may assign its own ID to the request.
+	if id := header.Get(requestIDHeader); id != "" {
+		meta.RequestID = id
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, path, bytes.NewReader(jsonBody))
+	return responseBody.LogLine, meta, status, err
+}
+
+func (api *createLogLineAPI) CallRaw(
+	ctx context.Context, instruction string, remix []string,
+) (*http.Response, error) {
+	if err := validateInstruction(instruction); err != nil {
+		return nil, err
+	}
+
+	if _, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return nil, err
+	}
+
+	remix, err := api.cfg.normalizeRemix(remix)
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	return api.cfg.doRaw(
+		ctx, http.MethodPut, api.cfg.apiPath("/log-lines"),
+		createLogLineRequest{Instruction: instruction, Remix: remix, N: 1},
+		editors...,
+	)
+}
+
+// Sends a request to generate a single log line.
+func (api *createLogLineAPI) create(
+	ctx context.Context, instruction string, remix []string, opts CreateOptions,
+) (createLogLineResponse, int, error) {
+	if err := validateInstruction(instruction); err != nil {
+		return createLogLineResponse{}, 0, err
+	}
+
+	if status, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return createLogLineResponse{}, status, err
+	}
+
+	remix, err := api.cfg.normalizeRemix(remix)
 	if err != nil {
-		return "", 0, err
+		return createLogLineResponse{}, 0, err
+	}
+
+	var editors []requestEditor
+
+	if opts.Lang != "" {
+		if opts.Lang, err = normalizeLang(opts.Lang); err != nil {
+			return createLogLineResponse{}, 0, err
+		}
+
+		editors = append(editors, withHeader("Accept-Language", opts.Lang))
 	}
 
-	if err := gatewayutils.EnsureStatus(res, http.StatusOK); err != nil {
-		return "", res.StatusCode, errors.Join(err, gatewayutils.GetResponseError(res))
+	// The key is computed once, so every attempt of the call shares it.
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
 	}
 
-	responseBody := new(struct{ logLine string })
-	if err := gatewayutils.ExtractJSONResponse(res, responseBody); err != nil {
-		return "", res.StatusCode, err
+	if etag, ok := ifNoneMatch(ctx); ok {
+		editors = append(editors, withHeader("If-None-Match", etag))
 	}
 
-	return responseBody.logLine, res.StatusCode, nil
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLine")
+	span.setAttributes(attribute.Int("gen_api.instruction.length", len(instruction)))
+
+	responseBody, status, err := doJSON[createLogLineRequest, createLogLineResponse](
+		ctx, api.cfg, http.MethodPut, api.cfg.apiPath("/log-lines"),
+		createLogLineRequest{
+			Instruction: instruction,
+			Remix:       remix,
+			N:           1,
+			Temperature: opts.Temperature,
+			MaxLength:   opts.MaxLength,
+			Seed:        opts.Seed,
+			Lang:        opts.Lang,
+		},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody, status, err
 }
 
-func (api *createLogLineAPI) Mock(_ context.Context, useCase string) (string, int, error) {
+func (api *createLogLineAPI) CreateMany(
+	ctx context.Context, instruction string, remix []string, n int,
+) ([]string, int, error) {
+	if err := validateInstruction(instruction); err != nil {
+		return nil, 0, err
+	}
+
+	if n < 1 {
+		return nil, 0, fmt.Errorf("%w: at least 1 log line must be requested, got %d", ErrInvalidArgument, n)
+	}
+
+	if status, err := api.moderateInstruction(ctx, instruction); err != nil {
+		return nil, status, err
+	}
+
+	remix, err := api.cfg.normalizeRemix(remix)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var editors []requestEditor
+	if key, ok := api.cfg.idempotencyKey(ctx); ok {
+		editors = append(editors, withHeader(idempotencyKeyHeader, key))
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.CreateLogLines")
+	span.setAttributes(
+		attribute.Int("gen_api.instruction.length", len(instruction)),
+		attribute.Int("gen_api.candidates", n),
+	)
+
+	responseBody, status, err := doJSON[createLogLineRequest, createLogLinesResponse](
+		ctx, api.cfg, http.MethodPut, api.cfg.apiPath("/log-lines"),
+		createLogLineRequest{Instruction: instruction, Remix: remix, N: n},
+		http.StatusOK,
+		editors...,
+	)
+	span.end(status, err)
+
+	return responseBody.LogLines, status, err
+}
+
+func (api *createLogLineAPI) Mock(ctx context.Context, useCase MockUseCase) (string, int, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
 	if useCase == "" {
-		useCase = "success"
+		useCase = MockSuccess
 	}
 
-	mocked, ok := mocks.Create[useCase]
+	mocked, err := findCreateLogLineMock(api.mocks, string(useCase))
+	if err != nil {
+		return "", 0, err
+	}
 
-	if !ok {
-		return "", 0, fmt.Errorf("unknown use case: %s", useCase)
+	// Simulate a slow server, that the caller may give up on.
+	if mocked.Latency > 0 {
+		if err := api.cfg.sleep(ctx, mocked.Latency); err != nil {
+			return "", 0, err
+		}
 	}
 
-	return mocked.Result, mocked.Status, mocked.Err
+	return mocked.Result, mocked.Status, mocked.Err.error
 }
 
 // NewCreateLogLineAPI returns a new instance of CreateLogLineAPI.
 //
 // The endpoint is the root URL for accessing the Gen-API service.
-func NewCreateLogLineAPI(endpoint string) CreateLogLineAPI {
-	return &createLogLineAPI{endpoint: endpoint}
+func NewCreateLogLineAPI(endpoint string, opts ...Option) CreateLogLineAPI {
+	return &createLogLineAPI{cfg: newConfig([]string{endpoint}, opts)}
+}
+
+// NewCreateLogLineAPIWithMocks returns a new instance of CreateLogLineAPI, whose Mock method also uses the scenarios
+// read from the given YAML source.
+//
+// The source uses the same format as the mocks embedded in this package. Its scenarios are merged over the embedded
+// ones, so a use case can be replaced by declaring it again. An error is returned if the source is not valid YAML.
+func NewCreateLogLineAPIWithMocks(endpoint string, source io.Reader, opts ...Option) (CreateLogLineAPI, error) {
+	scenarios, err := readMocks(source)
+	if err != nil {
+		return nil, fmt.Errorf("read mocks: %w", err)
+	}
+
+	return &createLogLineAPI{cfg: newConfig([]string{endpoint}, opts), mocks: scenarios}, nil
 }
 
 // ValidateLogLineAPI sends a request to check if a given input is a valid log line.
@@ -121,72 +467,114 @@ type ValidateLogLineAPI interface {
 	//
 	// Otherwise, a 422 status will be returned to indicate the
 	// input does not match the requirements for a valid log line. This will result in the ErrInvalidLogLine error
-	// being thrown along.
+	// being thrown along. When the server explains why, the error is a *ValidationError wrapping ErrInvalidLogLine,
+	// that can be retrieved with errors.As.
 	//
-	// Any other status should be interpreted as an unexpected error.
+	// Any other status should be interpreted as an unexpected error. A 429 status comes with a RateLimitedError,
+	// telling how long to wait before trying again.
 	Call(ctx context.Context, logLine string) (int, error)
+	// ValidateBatch validates multiple log lines in a single request. The returned slice has one entry per log line,
+	// in the same order: nil if the log line is valid, and an error wrapping ErrInvalidLogLine otherwise.
+	//
+	// The status and error returned along describe the request itself, like for Call.
+	ValidateBatch(ctx context.Context, logLines []string) ([]error, int, error)
+	// ValidateAll validates multiple log lines in a single request, like ValidateBatch, but collapses the results into
+	// a single outcome: a 204 status if every log line is valid, and a 422 status along with an
+	// *InvalidLogLinesError, listing the offending log lines, otherwise. The error wraps ErrInvalidLogLine.
+	//
+	// A failed request returns its own status and error, like for ValidateBatch.
+	ValidateAll(ctx context.Context, logLines []string) (int, error)
+	// ClearCache removes every result from the cache of Call (see WithValidationCache).
+	ClearCache()
 	// Mock returns a mocked response, based on the chosen scenario.
-	Mock(ctx context.Context, useCase string) (int, error)
+	//
+	// If the scenario has a latency, Mock waits for it before returning, or returns the error of the context if it is
+	// done first. Like for a real call, the error of the context is returned right away if it is already done.
+	Mock(ctx context.Context, useCase MockUseCase) (int, error)
 }
 
 // Implements the ValidateLogLineAPI interface.
 type validateLogLineAPI struct {
-	// The root URL for accessing the Gen-API service.
-	endpoint string
+	// Configuration of the client.
+	cfg *config
+	// Mocked responses. The embedded ones are used if nil.
+	mocks *logLineMocks
 }
 
 func (api *validateLogLineAPI) Call(ctx context.Context, logLine string) (int, error) {
-	path, err := url.JoinPath(api.endpoint, "/api/v1/log-lines")
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
-	jsonBody, err := json.Marshal(map[string]interface{}{
-		"logLine": logLine,
-	})
-	if err != nil {
-		return 0, err
+	cacheKey := api.cfg.validationCacheKey(ctx, logLine)
+	if cached, ok := api.cfg.validationCache.get(cacheKey, api.cfg.clock.Now()); ok {
+		return cached.status, cached.err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(jsonBody))
-	if err != nil {
-		return 0, err
-	}
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.ValidateLogLine")
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
+	// A 422 status is turned into ErrInvalidLogLine by the shared error handling.
+	_, status, err := doJSON[validateLogLineRequest, struct{}](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/log-lines"),
+		validateLogLineRequest{LogLine: logLine},
+		http.StatusNoContent,
+	)
+	span.end(status, err)
 
-	// Special error for an expected use case.
-	if res.StatusCode == http.StatusUnprocessableEntity {
-		return res.StatusCode, ErrInvalidLogLine
-	}
+	api.cfg.validationCache.set(cacheKey, status, err, api.cfg.clock.Now())
 
-	if err := gatewayutils.EnsureStatus(res, http.StatusNoContent); err != nil {
-		return res.StatusCode, errors.Join(err, gatewayutils.GetResponseError(res))
+	if api.cfg.shouldFallbackToMock(ctx, "ValidateLogLine", err) {
+		return api.Mock(ctx, api.cfg.mockFallback)
 	}
 
-	return res.StatusCode, nil
+	return status, err
 }
 
-func (api *validateLogLineAPI) Mock(_ context.Context, useCase string) (int, error) {
+func (api *validateLogLineAPI) ClearCache() {
+	api.cfg.validationCache.clear()
+}
+
+func (api *validateLogLineAPI) Mock(ctx context.Context, useCase MockUseCase) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	if useCase == "" {
-		useCase = "success"
+		useCase = MockSuccess
 	}
 
-	mocked, ok := mocks.Validate[useCase]
+	mocked, err := findValidateLogLineMock(api.mocks, string(useCase))
+	if err != nil {
+		return 0, err
+	}
 
-	if !ok {
-		return 0, fmt.Errorf("unknown use case: %s", useCase)
+	// Simulate a slow server, that the caller may give up on.
+	if mocked.Latency > 0 {
+		if err := api.cfg.sleep(ctx, mocked.Latency); err != nil {
+			return 0, err
+		}
 	}
 
-	return mocked.Status, mocked.Err
+	return mocked.Status, mocked.Err.error
 }
 
 // NewValidateLogLineAPI returns a new instance of ValidateLogLineAPI.
 //
 // The endpoint is the root URL for accessing the Gen-API service.
-func NewValidateLogLineAPI(endpoint string) ValidateLogLineAPI {
-	return &validateLogLineAPI{endpoint: endpoint}
+func NewValidateLogLineAPI(endpoint string, opts ...Option) ValidateLogLineAPI {
+	return &validateLogLineAPI{cfg: newConfig([]string{endpoint}, opts)}
+}
+
+// NewValidateLogLineAPIWithMocks returns a new instance of ValidateLogLineAPI, whose Mock method also uses the
+// scenarios read from the given YAML source.
+//
+// The source uses the same format as the mocks embedded in this package. Its scenarios are merged over the embedded
+// ones, so a use case can be replaced by declaring it again. An error is returned if the source is not valid YAML.
+func NewValidateLogLineAPIWithMocks(endpoint string, source io.Reader, opts ...Option) (ValidateLogLineAPI, error) {
+	scenarios, err := readMocks(source)
+	if err != nil {
+		return nil, fmt.Errorf("read mocks: %w", err)
+	}
+
+	return &validateLogLineAPI{cfg: newConfig([]string{endpoint}, opts), mocks: scenarios}, nil
 }
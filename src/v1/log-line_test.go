@@ -0,0 +1,452 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCreateLogLineCallDecodesLogLine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut || req.URL.Path != "/api/v1/log-lines" {
+			http.NotFound(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"logLine":"the generated log line"}`))
+	}))
+	defer server.Close()
+
+	api := NewCreateLogLineAPI(server.URL)
+
+	logLine, status, err := api.Call(context.Background(), "an instruction", nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, status)
+	}
+
+	if logLine != "the generated log line" {
+		t.Errorf("expected the log line of the response, got %q", logLine)
+	}
+}
+
+// A RoundTripper that tracks whether the bodies of the responses it returns are closed.
+type bodyTracker struct {
+	mu sync.Mutex
+	// Number of response bodies returned, and not closed yet.
+	open int
+	// Number of response bodies returned.
+	total int
+}
+
+func (tracker *bodyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker.mu.Lock()
+	tracker.open++
+	tracker.total++
+	tracker.mu.Unlock()
+
+	res.Body = &trackedResponseBody{ReadCloser: res.Body, tracker: tracker}
+
+	return res, nil
+}
+
+// Checks that every response body was closed.
+func (tracker *bodyTracker) assertClosed(t *testing.T) {
+	t.Helper()
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.total == 0 {
+		t.Fatal("expected at least one response")
+	}
+
+	if tracker.open != 0 {
+		t.Errorf("expected every response body to be closed, %d of %d left open", tracker.open, tracker.total)
+	}
+}
+
+// A response body tracked by a bodyTracker.
+type trackedResponseBody struct {
+	io.ReadCloser
+	tracker *bodyTracker
+	once    sync.Once
+}
+
+func (body *trackedResponseBody) Close() error {
+	body.once.Do(func() {
+		body.tracker.mu.Lock()
+		body.tracker.open--
+		body.tracker.mu.Unlock()
+	})
+
+	return body.ReadCloser.Close()
+}
+
+func TestResponseBodiesAreClosed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		response testutil.FakeResponse
+		call     func(ctx context.Context, client *Client) error
+	}{
+		{
+			name:     "CreateSuccess",
+			method:   http.MethodPut,
+			path:     "/api/v1/log-lines",
+			response: testutil.FakeResponse{Body: map[string]string{"logLine": "a log line"}},
+			call: func(ctx context.Context, client *Client) error {
+				_, _, err := client.LogLines.Create.Call(ctx, "an instruction", nil)
+				return err
+			},
+		},
+		{
+			name:     "CreateError",
+			method:   http.MethodPut,
+			path:     "/api/v1/log-lines",
+			response: testutil.FakeResponse{Status: http.StatusInternalServerError, Body: map[string]string{"error": "boom"}},
+			call: func(ctx context.Context, client *Client) error {
+				_, _, err := client.LogLines.Create.Call(ctx, "an instruction", nil)
+				return err
+			},
+		},
+		{
+			name:     "ValidateSuccess",
+			method:   http.MethodPost,
+			path:     "/api/v1/log-lines",
+			response: testutil.FakeResponse{Status: http.StatusNoContent},
+			call: func(ctx context.Context, client *Client) error {
+				_, err := client.LogLines.Validate.Call(ctx, "a log line")
+				return err
+			},
+		},
+		{
+			name:     "ValidateInvalid",
+			method:   http.MethodPost,
+			path:     "/api/v1/log-lines",
+			response: testutil.FakeResponse{Status: http.StatusUnprocessableEntity, Body: map[string]string{"error": "bad"}},
+			call: func(ctx context.Context, client *Client) error {
+				_, err := client.LogLines.Validate.Call(ctx, "a log line")
+				return err
+			},
+		},
+		{
+			name:     "PingSuccess",
+			method:   http.MethodGet,
+			path:     "/ping",
+			response: testutil.FakeResponse{Status: http.StatusOK},
+			call: func(ctx context.Context, client *Client) error {
+				_, err := client.Ping.Call(ctx)
+				return err
+			},
+		},
+		{
+			name:     "PingError",
+			method:   http.MethodGet,
+			path:     "/ping",
+			response: testutil.FakeResponse{Status: http.StatusInternalServerError},
+			call: func(ctx context.Context, client *Client) error {
+				_, err := client.Ping.Call(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(testCase.method, testCase.path, testCase.response)
+
+			tracker := new(bodyTracker)
+			client := NewClient(server.URL, WithTransport(tracker))
+
+			_ = testCase.call(context.Background(), client)
+
+			tracker.assertClosed(t)
+		})
+	}
+}
+
+func TestCreateWithMeta(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	body := map[string]any{
+		"logLine": "a log line",
+		"model":   "a-model",
+		"usage":   map[string]int{"promptTokens": 12, "completionTokens": 34},
+	}
+
+	t.Run("RequestIDFromServer", func(t *testing.T) {
+		server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+			Header: http.Header{requestIDHeader: {"from-server"}, "Etag": {`"v1"`}},
+			Body:   body,
+		})
+
+		api := NewCreateLogLineAPI(server.URL)
+
+		logLine, meta, _, err := api.CreateWithMeta(WithRequestID(context.Background(), "sent"), "an instruction", nil)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		if logLine != "a log line" {
+			t.Errorf("expected the log line of the response, got %q", logLine)
+		}
+
+		expected := CreateMeta{
+			Model: "a-model", PromptTokens: 12, CompletionTokens: 34, RequestID: "from-server", ETag: `"v1"`,
+		}
+		if meta != expected {
+			t.Errorf("expected meta %+v, got %+v", expected, meta)
+		}
+	})
+
+	t.Run("RequestIDFallback", func(t *testing.T) {
+		server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{Body: body})
+
+		api := NewCreateLogLineAPI(server.URL)
+
+		_, meta, _, err := api.CreateWithMeta(WithRequestID(context.Background(), "sent"), "an instruction", nil)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		if meta.RequestID != "sent" {
+			t.Errorf("expected the request ID sent by the client, got %q", meta.RequestID)
+		}
+	})
+}
+
+func TestCanceledContext(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	create, validate, analyze := client.LogLines.Create, client.LogLines.Validate, client.LogLines.Analyze
+
+	// Returns the error sent on the channel of a streaming method, once its channel of results is drained.
+	streamErr := func(results <-chan string, errs <-chan error) error {
+		for range results {
+		}
+
+		return <-errs
+	}
+
+	testCases := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{name: "Create.Call", call: func(ctx context.Context) error {
+			_, _, err := create.Call(ctx, "an instruction", nil)
+			return err
+		}},
+		{name: "Create.CallWithOptions", call: func(ctx context.Context) error {
+			_, _, err := create.CallWithOptions(ctx, "an instruction", nil, CreateOptions{})
+			return err
+		}},
+		{name: "Create.CreateWithMeta", call: func(ctx context.Context) error {
+			_, _, _, err := create.CreateWithMeta(ctx, "an instruction", nil)
+			return err
+		}},
+		{name: "Create.CallRaw", call: func(ctx context.Context) error {
+			res, err := create.CallRaw(ctx, "an instruction", nil)
+			if res != nil {
+				_ = res.Body.Close()
+			}
+
+			return err
+		}},
+		{name: "Create.CreateMany", call: func(ctx context.Context) error {
+			_, _, err := create.CreateMany(ctx, "an instruction", nil, 2)
+			return err
+		}},
+		{name: "Create.CreateManyStream", call: func(ctx context.Context) error {
+			return streamErr(create.CreateManyStream(ctx, "an instruction", nil, 2))
+		}},
+		{name: "Create.CreateStream", call: func(ctx context.Context) error {
+			return streamErr(create.CreateStream(ctx, "an instruction", nil))
+		}},
+		{name: "Create.CreateVariations", call: func(ctx context.Context) error {
+			_, _, err := create.CreateVariations(ctx, "a log line", 2)
+			return err
+		}},
+		{name: "Create.Refine", call: func(ctx context.Context) error {
+			_, _, err := create.Refine(ctx, "a log line", "an instruction")
+			return err
+		}},
+		{name: "Create.CreateBatch", call: func(ctx context.Context) error {
+			results := create.CreateBatch(ctx, []CreateRequest{{Instruction: "an instruction"}}, 1)
+			return results[0].Err
+		}},
+		{name: "Create.CreateAsyncJob", call: func(ctx context.Context) error {
+			_, err := create.CreateAsyncJob(ctx, "an instruction", nil)
+			return err
+		}},
+		{name: "Create.CreateAsyncJobWithCallback", call: func(ctx context.Context) error {
+			_, err := create.CreateAsyncJobWithCallback(ctx, "an instruction", nil, "https://example.com/callback")
+			return err
+		}},
+		{name: "Create.PollResult", call: func(ctx context.Context) error {
+			_, _, _, err := create.PollResult(ctx, "fake-job")
+			return err
+		}},
+		{name: "Create.CreateAwait", call: func(ctx context.Context) error {
+			_, _, err := create.CreateAwait(ctx, "an instruction", nil, time.Millisecond)
+			return err
+		}},
+		{name: "Create.CreateAsync", call: func(ctx context.Context) error {
+			return (<-create.CreateAsync(ctx, "an instruction", nil)).Err
+		}},
+		{name: "Create.GenerateTitle", call: func(ctx context.Context) error {
+			_, _, err := create.GenerateTitle(ctx, "a log line")
+			return err
+		}},
+		{name: "Create.Translate", call: func(ctx context.Context) error {
+			_, _, err := create.Translate(ctx, "a log line", "fr")
+			return err
+		}},
+		{name: "Create.Moderate", call: func(ctx context.Context) error {
+			_, _, err := create.Moderate(ctx, "a text")
+			return err
+		}},
+		{name: "Create.Mock", call: func(ctx context.Context) error {
+			_, _, err := create.Mock(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Create.MockVariations", call: func(ctx context.Context) error {
+			_, _, err := create.MockVariations(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Create.MockGenerateTitle", call: func(ctx context.Context) error {
+			_, _, err := create.MockGenerateTitle(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Create.MockTranslate", call: func(ctx context.Context) error {
+			_, _, err := create.MockTranslate(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Create.MockModerate", call: func(ctx context.Context) error {
+			_, _, err := create.MockModerate(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Create.MockRefine", call: func(ctx context.Context) error {
+			_, _, err := create.MockRefine(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Validate.Call", call: func(ctx context.Context) error {
+			_, err := validate.Call(ctx, "a log line")
+			return err
+		}},
+		{name: "Validate.ValidateBatch", call: func(ctx context.Context) error {
+			_, _, err := validate.ValidateBatch(ctx, []string{"a log line"})
+			return err
+		}},
+		{name: "Validate.ValidateAll", call: func(ctx context.Context) error {
+			_, err := validate.ValidateAll(ctx, []string{"a log line"})
+			return err
+		}},
+		{name: "Validate.Mock", call: func(ctx context.Context) error {
+			_, err := validate.Mock(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Analyze.Score", call: func(ctx context.Context) error {
+			_, _, err := analyze.Score(ctx, "a log line")
+			return err
+		}},
+		{name: "Analyze.DetectLanguage", call: func(ctx context.Context) error {
+			_, _, _, err := analyze.DetectLanguage(ctx, "a log line")
+			return err
+		}},
+		{name: "Analyze.ExtractKeywords", call: func(ctx context.Context) error {
+			_, _, err := analyze.ExtractKeywords(ctx, "a log line")
+			return err
+		}},
+		{name: "Analyze.MockScore", call: func(ctx context.Context) error {
+			_, _, err := analyze.MockScore(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Analyze.MockDetectLanguage", call: func(ctx context.Context) error {
+			_, _, _, err := analyze.MockDetectLanguage(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Analyze.MockExtractKeywords", call: func(ctx context.Context) error {
+			_, _, err := analyze.MockExtractKeywords(ctx, MockSuccess)
+			return err
+		}},
+		{name: "Ping.Call", call: func(ctx context.Context) error {
+			_, err := client.Ping.Call(ctx)
+			return err
+		}},
+		{name: "Ping.Live", call: func(ctx context.Context) error {
+			_, err := client.Ping.Live(ctx)
+			return err
+		}},
+		{name: "Ping.Ready", call: func(ctx context.Context) error {
+			_, err := client.Ping.Ready(ctx)
+			return err
+		}},
+		{name: "Ping.PingWait", call: func(ctx context.Context) error {
+			_, err := client.Ping.PingWait(ctx, time.Millisecond)
+			return err
+		}},
+		{name: "Ping.PingLatency", call: func(ctx context.Context) error {
+			_, _, err := client.Ping.PingLatency(ctx)
+			return err
+		}},
+		{name: "Client.Do", call: func(ctx context.Context) error {
+			_, err := client.Do(ctx, http.MethodGet, "/ping", nil, nil, http.StatusOK)
+			return err
+		}},
+		{name: "Client.CreateValidated", call: func(ctx context.Context) error {
+			_, _, _, err := client.CreateValidated(ctx, "an instruction", nil, 2)
+			return err
+		}},
+		{name: "Client.ServerVersion", call: func(ctx context.Context) error {
+			_, err := client.ServerVersion(ctx)
+			return err
+		}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if err := testCase.call(ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+
+	if requests := server.Requests(); len(requests) > 0 {
+		t.Errorf("expected no request to be sent, got %d", len(requests))
+	}
+
+	t.Run("Ping.Monitor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		for result := range client.Ping.Monitor(ctx, time.Millisecond) {
+			t.Errorf("expected no result, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogger emits a debug record for each request sent to the Gen-API service, with the method, endpoint, path,
+// status, latency and attempt number of the request. Nothing is logged by default.
+//
+// Request bodies are redacted, unless WithLogBodies is also used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// WithLogBodies includes the body of the requests in the records emitted by the logger (see WithLogger), and the
+// bodies of the requests and responses in the dumps of WithDebugHTTP. Bodies carry the instructions and log lines of
+// the users, so this should only be enabled for debugging.
+func WithLogBodies(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.logBodies = enabled
+	}
+}
+
+// Reports a request sent to the Gen-API service, along with its outcome, to the logger, the metrics and the
+// deprecation callback. The attempt number starts at 1.
+func (cfg *config) observeRequest(
+	ctx context.Context, req *http.Request, res *http.Response, err error, attempt int, latency time.Duration,
+) {
+	cfg.logRequest(ctx, req, res, err, attempt, latency)
+	if cfg.metrics != nil {
+		cfg.metrics.observe(cfg.endpointLabel(req), res, latency)
+	}
+	cfg.checkDeprecation(ctx, req, res)
+}
+
+// Logs a request sent to the Gen-API service, along with its outcome. The attempt number starts at 1.
+func (cfg *config) logRequest(
+	ctx context.Context, req *http.Request, res *http.Response, err error, attempt int, latency time.Duration,
+) {
+	if cfg.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", endpointOf(req)),
+		slog.String("path", req.URL.Path),
+		slog.Duration("latency", latency),
+		slog.Int("attempt", attempt),
+	}
+
+	if res != nil {
+		attrs = append(attrs, slog.Int("status", res.StatusCode))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("requestID", id))
+	}
+
+	if cfg.logBodies {
+		attrs = append(attrs, slog.String("body", requestBody(req)))
+	}
+
+	cfg.logger.LogAttrs(ctx, slog.LevelDebug, "gen-api request", attrs...)
+}
+
+// Reads a copy of the body of a request, without consuming it. Compressed bodies are decompressed.
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	var reader io.Reader = body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		if reader, err = gzip.NewReader(body); err != nil {
+			return ""
+		}
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return ""
+	}
+
+	return string(content)
+}
+
+// Returns the logger for warnings: the configured one, or the default logger of slog.
+func (cfg *config) warnLogger() *slog.Logger {
+	if cfg.logger == nil {
+		return slog.Default()
+	}
+
+	return cfg.logger
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Status classes used to label the metrics of a request.
+const (
+	// No response was received.
+	statusClassError = "error"
+	// 422 responses, that reject a log line: this is a legitimate answer of the validate API, not a failure.
+	statusClassInvalidLogLine = "invalid_log_line"
+)
+
+// Endpoint label of the requests sent to an endpoint set by WithEndpointOverride, rather than to an endpoint of the
+// client.
+const endpointLabelOverride = "override"
+
+// WithMetrics records Prometheus metrics for each request sent to the Gen-API service, in the given registerer:
+//   - gen_api_requests_total counts the requests, by endpoint and status class ("2xx", "4xx", "5xx", etc.). 422
+//     responses are counted under the "invalid_log_line" class, and requests that received no response under the
+//     "error" class.
+//   - gen_api_request_duration_seconds measures the latency of the requests, by endpoint.
+//
+// The endpoint label is one of the endpoints of the client, without its path. Requests sent to an endpoint set by
+// WithEndpointOverride are labeled "override", so the number of series does not grow with the number of tenants.
+//
+// The metrics are shared by the clients using the same registerer. No metric is recorded by default.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(cfg *config) {
+		cfg.metrics = newRequestMetrics(reg)
+	}
+}
+
+// Metrics of the requests sent to the Gen-API service. A nil requestMetrics records nothing.
+type requestMetrics struct {
+	// Number of requests, by endpoint and status class.
+	requests *prometheus.CounterVec
+	// Latency of the requests, by endpoint.
+	latency *prometheus.HistogramVec
+}
+
+// Creates the metrics, and registers them. Metrics already registered by another client are reused.
+func newRequestMetrics(reg prometheus.Registerer) *requestMetrics {
+	return &requestMetrics{
+		requests: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gen_api_requests_total",
+			Help: "Number of requests sent to the Gen-API service.",
+		}, []string{"endpoint", "status_class"})),
+		latency: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gen_api_request_duration_seconds",
+			Help:    "Latency of the requests sent to the Gen-API service.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"endpoint"})),
+	}
+}
+
+// Registers the collector, or returns the equivalent collector that is already registered, for example by another
+// client sharing the same registerer.
+func register[Collector prometheus.Collector](reg prometheus.Registerer, collector Collector) Collector {
+	err := reg.Register(collector)
+	if err == nil {
+		return collector
+	}
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		if existing, ok := alreadyRegistered.ExistingCollector.(Collector); ok {
+			return existing
+		}
+	}
+
+	// The collector clashes with an unrelated one: this is a programming error.
+	panic(err)
+}
+
+// Records a request sent to the Gen-API service, to the given endpoint label (see endpointLabel).
+func (metrics *requestMetrics) observe(endpoint string, res *http.Response, latency time.Duration) {
+	if metrics == nil {
+		return
+	}
+
+	metrics.requests.WithLabelValues(endpoint, statusClass(res)).Inc()
+	metrics.latency.WithLabelValues(endpoint).Observe(latency.Seconds())
+}
+
+// Returns the endpoint label of a request: the endpoint of the client it was sent to, or "override".
+func (cfg *config) endpointLabel(req *http.Request) string {
+	if !cfg.isEndpointHost(req.URL) {
+		return endpointLabelOverride
+	}
+
+	return endpointOf(req)
+}
+
+// Returns the class of the status of a response, like "2xx".
+func statusClass(res *http.Response) string {
+	if res == nil {
+		return statusClassError
+	}
+
+	if res.StatusCode == http.StatusUnprocessableEntity {
+		return statusClassInvalidLogLine
+	}
+
+	return strconv.Itoa(res.StatusCode/100) + "xx"
+}
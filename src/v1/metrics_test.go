@@ -0,0 +1,151 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+// Registers collectors by name, like a prometheus.Registry, and rejects the ones already registered.
+type nameRegisterer struct {
+	// Wraps the AlreadyRegisteredError, as registerers built on top of another one may do.
+	wrap bool
+	// Registered collectors, in order.
+	collectors []prometheus.Collector
+}
+
+func (reg *nameRegisterer) Register(collector prometheus.Collector) error {
+	for _, existing := range reg.collectors {
+		if fmt.Sprintf("%T", existing) == fmt.Sprintf("%T", collector) {
+			var err error = prometheus.AlreadyRegisteredError{ExistingCollector: existing, NewCollector: collector}
+			if reg.wrap {
+				err = fmt.Errorf("register: %w", err)
+			}
+
+			return err
+		}
+	}
+
+	reg.collectors = append(reg.collectors, collector)
+
+	return nil
+}
+
+func (reg *nameRegisterer) MustRegister(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (reg *nameRegisterer) Unregister(prometheus.Collector) bool {
+	return false
+}
+
+func TestMetricsShareRegisterer(t *testing.T) {
+	testCases := []struct {
+		name string
+		wrap bool
+	}{
+		{name: "AlreadyRegistered", wrap: false},
+		{name: "WrappedAlreadyRegistered", wrap: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			reg := &nameRegisterer{wrap: testCase.wrap}
+
+			first := newConfig([]string{"http://localhost"}, []Option{WithMetrics(reg)})
+			second := newConfig([]string{"http://localhost"}, []Option{WithMetrics(reg)})
+
+			if first.metrics.requests != second.metrics.requests {
+				t.Error("expected the clients to share the request counter")
+			}
+
+			if first.metrics.latency != second.metrics.latency {
+				t.Error("expected the clients to share the latency histogram")
+			}
+
+			if len(reg.collectors) != 2 {
+				t.Errorf("expected 2 registered collectors, got %d", len(reg.collectors))
+			}
+		})
+	}
+}
+
+func TestMetricsEndpointLabel(t *testing.T) {
+	server, tenant := testutil.NewFakeServer(), testutil.NewFakeServer()
+	defer server.Close()
+	defer tenant.Close()
+
+	reg := prometheus.NewRegistry()
+	api := NewCreateLogLineAPI(server.URL, WithMetrics(reg))
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	ctx := WithEndpointOverride(context.Background(), tenant.URL)
+	if _, _, err := api.Call(ctx, "an instruction", nil); err != nil {
+		t.Fatalf("failed to create a log line with an override: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather the metrics: %v", err)
+	}
+
+	// Number of requests, by endpoint label.
+	requests := make(map[string]float64)
+
+	for _, family := range families {
+		if family.GetName() != "gen_api_requests_total" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "endpoint" {
+					requests[label.GetValue()] += metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	// Endpoints of the client are labeled as such, and overrides share a single label, whatever their endpoint.
+	expected := map[string]float64{server.URL: 1, endpointLabelOverride: 1}
+	if !maps.Equal(requests, expected) {
+		t.Errorf("expected the requests %v, got %v", expected, requests)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	testCases := []struct {
+		name     string
+		res      *http.Response
+		expected string
+	}{
+		{name: "NoResponse", res: nil, expected: statusClassError},
+		{name: "OK", res: &http.Response{StatusCode: http.StatusOK}, expected: "2xx"},
+		{name: "NotFound", res: &http.Response{StatusCode: http.StatusNotFound}, expected: "4xx"},
+		{
+			name:     "InvalidLogLine",
+			res:      &http.Response{StatusCode: http.StatusUnprocessableEntity},
+			expected: statusClassInvalidLogLine,
+		},
+		{name: "BadGateway", res: &http.Response{StatusCode: http.StatusBadGateway}, expected: "5xx"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := statusClass(testCase.res); got != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
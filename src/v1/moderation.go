@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"strings"
+)
+
+// ErrContentFlagged is wrapped by the errors returned when an instruction is flagged by the moderation of the Gen-API
+// service (see WithPreModeration).
+var ErrContentFlagged = errors.New("content flagged by moderation")
+
+// ContentFlaggedError is returned by the create calls when their instruction is flagged by the moderation. It wraps
+// ErrContentFlagged.
+type ContentFlaggedError struct {
+	// Categories of disallowed content the instruction was flagged for.
+	Categories []string
+}
+
+func (err *ContentFlaggedError) Error() string {
+	if len(err.Categories) == 0 {
+		return ErrContentFlagged.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", ErrContentFlagged, strings.Join(err.Categories, ", "))
+}
+
+func (err *ContentFlaggedError) Unwrap() error {
+	return ErrContentFlagged
+}
+
+// ModerationResult is the verdict of the moderation of a text.
+type ModerationResult struct {
+	// Whether the text contains disallowed content.
+	Flagged bool `json:"flagged" yaml:"flagged"`
+	// Categories of disallowed content found in the text, if any.
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// Body of a moderation request.
+type moderationRequest struct {
+	Text string `json:"text"`
+}
+
+// WithPreModeration checks the instruction of every create call with the moderation of the Gen-API service (see
+// CreateLogLineAPI.Moderate), before generating anything. A flagged instruction aborts the call with a
+// *ContentFlaggedError, so disallowed prompts never reach the generation model.
+//
+// This applies to every create method that takes an instruction, including Refine. It costs an extra request per
+// call.
+func WithPreModeration() Option {
+	return func(cfg *config) {
+		cfg.preModeration = true
+	}
+}
+
+func (api *createLogLineAPI) Moderate(ctx context.Context, text string) (ModerationResult, int, error) {
+	if strings.TrimSpace(text) == "" {
+		return ModerationResult{}, 0, fmt.Errorf("%w: empty text", ErrInvalidArgument)
+	}
+
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.Moderate")
+	span.setAttributes(attribute.Int("gen_api.text.length", len(text)))
+
+	responseBody, status, err := doJSON[moderationRequest, ModerationResult](
+		ctx, api.cfg, http.MethodPost, api.cfg.apiPath("/moderation"),
+		moderationRequest{Text: text},
+		http.StatusOK,
+	)
+	span.end(status, err)
+
+	return responseBody, status, err
+}
+
+// Moderates the instruction of a create call, when pre-moderation is enabled. A *ContentFlaggedError is returned if
+// the instruction is flagged, with a 0 status, since no generation request is sent.
+func (api *createLogLineAPI) moderateInstruction(ctx context.Context, instruction string) (int, error) {
+	if !api.cfg.preModeration {
+		return 0, nil
+	}
+
+	result, status, err := api.Moderate(ctx, instruction)
+	if err != nil {
+		return status, fmt.Errorf("moderate instruction: %w", err)
+	}
+
+	if result.Flagged {
+		return 0, &ContentFlaggedError{Categories: result.Categories}
+	}
+
+	return 0, nil
+}
+
+func (api *createLogLineAPI) MockModerate(ctx context.Context, useCase MockUseCase) (ModerationResult, int, error) {
+	return mockResult(ctx, api.cfg, api.mocks, moderationMocks, useCase)
+}
+
+// Returns the scenarios of MockModerate.
+func moderationMocks(mocks *logLineMocks) map[string]resultMock[ModerationResult] {
+	return mocks.Moderate
+}
@@ -0,0 +1,272 @@
+package v1
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option customizes the behavior of a Gen-API client.
+//
+// Options are shared by every constructor of this package, so the same list can be used to configure
+// all the APIs at once.
+type Option func(*config)
+
+// Configuration shared by the Gen-API clients, built from a list of Option.
+type config struct {
+	// The root URLs for accessing the Gen-API service. Requests are spread across them.
+	endpoints []string
+	// Index of the endpoint to use for the next request, modulo the number of endpoints.
+	nextEndpoint atomic.Uint64
+	// Tracks the endpoints that look down. Every endpoint is used if nil.
+	failover *endpointHealth
+	// Routes the calls to the fastest endpoint. Calls are spread across the endpoints if nil.
+	latencyRouting *latencyRouter
+	// Receives a record for each request. Nothing is logged if nil.
+	logger *slog.Logger
+	// Whether the records of the logger include the body of the requests.
+	logBodies bool
+	// Dumps the requests and responses. Nothing is dumped if nil.
+	debugHTTP *httpDumper
+	// Metrics of the requests. Nothing is recorded if nil.
+	metrics *requestMetrics
+	// The HTTP client used to send requests to the Gen-API service.
+	httpClient *http.Client
+	// Transport of the HTTP client built when none is provided. Not used if nil.
+	transport http.RoundTripper
+	// Cookie jar of the HTTP client built when none is provided. Not used if nil.
+	cookieJar http.CookieJar
+	// Decides whether the HTTP client built when none is provided follows a redirect. Up to 10 redirects are
+	// followed if nil.
+	redirectPolicy func(req *http.Request, via []*http.Request) error
+	// Certificates presented by the HTTP client built when none is provided, for mutual TLS.
+	clientCertificates []tls.Certificate
+	// Authorities trusted by the HTTP client built when none is provided. The ones of the system are used if nil.
+	rootCAs *x509.CertPool
+	// Limits of the connections of the HTTP client built when none is provided. Unlimited if not positive.
+	maxConnsPerHost, maxIdleConns int
+	// Whether the HTTP client built when none is provided uses HTTP/2. The default of the transport is kept if nil.
+	http2 *bool
+	// Headers added to every request.
+	headers http.Header
+	// Compresses the bodies of the requests. Bodies are sent as-is if nil.
+	compression *requestCompression
+	// Whether to ask the server for gzip-compressed responses.
+	acceptGzip bool
+	// Maximum number of remix entries of a create call. Unlimited if not positive.
+	maxRemix int
+	// Receives the deprecation notices of the responses. A warning is logged once per route if nil.
+	onDeprecation func(endpoint, message string)
+	// Routes already reported as deprecated by the default warning.
+	deprecationWarnings sync.Map
+	// Minimum version of the service required by the calls. Not checked if empty.
+	minServerVersion string
+	// Version of the service, once detected. It is empty if the service does not advertise its version.
+	serverVersion atomic.Pointer[string]
+	// Tracks the calls in flight.
+	calls callTracker
+	// Tells the time, and waits.
+	clock Clock
+	// Caches the results of the validate calls. Nothing is cached if nil.
+	validationCache *validationCache
+	// Deduplicates the concurrent identical create calls. Every call sends its own request if nil.
+	singleflight *singleflight.Group
+	// Receives the headers of each successful response. Not called if nil.
+	onResponseHeader func(http.Header)
+	// Receives the timings of each call. Timings are not collected if nil.
+	onTiming func(TimingInfo)
+	// Maximum duration of a single call. No deadline is imposed by the client if zero.
+	timeout time.Duration
+	// The value of the User-Agent header sent with every request.
+	userAgent string
+	// Version of the log line routes of the Gen-API service, e.g. "v1".
+	apiVersion string
+	// Provides the bearer token used to authenticate requests. Requests are not authenticated if nil.
+	tokenSource TokenSource
+	// Whether a request rejected with a 401 status is sent again with a new token.
+	authRetry bool
+	// Name of the header carrying the API key. No key is sent if empty.
+	apiKeyHeader string
+	// Static key used to authenticate requests.
+	apiKey string
+	// Generates the request ID of calls whose context does not carry one.
+	requestIDGenerator func() string
+	// Traces the calls. Calls are not traced if nil.
+	tracer trace.Tracer
+	// Maximum size of the response bodies, in bytes. Bodies are not limited if zero or negative.
+	maxResponseBytes int64
+	// Encodes requests and decodes responses.
+	codec Codec
+	// Limits the rate of requests. Requests are not limited if nil.
+	limiter *rate.Limiter
+	// Stops sending requests while the service looks down. Requests are always sent if nil.
+	breaker *circuitBreaker
+	// Rolling window over which the breaker counts the failures. Consecutive failures are counted if zero.
+	breakerWindow time.Duration
+	// Share of the attempts of the window that must fail to open the breaker.
+	breakerFailureRate float64
+	// Use case of the mocked response returned when the service is unreachable. Calls fail normally if empty.
+	mockFallback MockUseCase
+	// Whether the instruction of the create calls is moderated before generating anything.
+	preModeration bool
+	// How failed calls are retried.
+	retry retryPolicy
+}
+
+// WithHTTPClient sets the HTTP client used to send requests to the Gen-API service.
+//
+// If not set, or set to nil, http.DefaultClient is used, unless the client needs to be customized by another option,
+// like WithTransport, WithCookieJar or WithClientCertificate. The client takes precedence over these options.
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.httpClient = client
+	}
+}
+
+// WithTransport sets the transport used to send requests to the Gen-API service, for example to add middlewares below
+// the HTTP client. The transport is wrapped in a new HTTP client, and is ignored if WithHTTPClient is also given.
+//
+// The transport composes with WithTimeout, whose deadline is carried by the context of each request.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(cfg *config) {
+		cfg.transport = transport
+	}
+}
+
+// WithCookieJar stores the cookies set by the Gen-API service in the given jar, and sends them back with the next
+// requests. It is ignored if WithHTTPClient is also given.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(cfg *config) {
+		cfg.cookieJar = jar
+	}
+}
+
+// WithStickySession keeps the cookies set by the Gen-API service for the life of the client, so a gateway using a
+// session cookie routes every request to the same instance of the service. It uses an in-memory cookie jar, and is
+// replaced by WithCookieJar if both are given.
+func WithStickySession() Option {
+	return func(cfg *config) {
+		if cfg.cookieJar == nil {
+			// Never fails without options.
+			cfg.cookieJar, _ = cookiejar.New(nil)
+		}
+	}
+}
+
+// WithTimeout bounds the duration of each call, including the time spent reading the response.
+//
+// The timeout is applied on top of the context passed to the call: if this context already has an earlier deadline,
+// the earlier one wins. A zero (or negative) timeout means no deadline is imposed by the client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithHeaders adds the given headers to every request sent to the Gen-API service. Calling it multiple times merges
+// the headers together.
+//
+// The Content-Type header is reserved for the encoding of the request body, and is ignored. Headers set by other
+// options, like authentication or the User-Agent, take precedence over the ones given here.
+func WithHeaders(headers http.Header) Option {
+	return func(cfg *config) {
+		if cfg.headers == nil {
+			cfg.headers = make(http.Header, len(headers))
+		}
+
+		for key, values := range headers.Clone() {
+			key = http.CanonicalHeaderKey(key)
+			cfg.headers[key] = append(cfg.headers[key], values...)
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request to the Gen-API service.
+//
+// It defaults to "gen-api-proxy/<Version>".
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *config) {
+		cfg.userAgent = userAgent
+	}
+}
+
+// WithResponseHeaderCallback calls the given function with the headers of each successful response of the Gen-API
+// service, for example to monitor the X-RateLimit-Remaining and X-RateLimit-Reset headers, and slow down before
+// getting rate limited.
+//
+// The callback receives a copy of the headers, that it is free to keep or modify. It is called synchronously, before
+// the call returns, and can be called concurrently by parallel calls.
+func WithResponseHeaderCallback(callback func(http.Header)) Option {
+	return func(cfg *config) {
+		cfg.onResponseHeader = callback
+	}
+}
+
+// Builds the HTTP client used when none is provided: http.DefaultClient, unless the client needs customizations.
+func (cfg *config) newHTTPClient() *http.Client {
+	transport := cfg.newTransport()
+	authenticated := cfg.tokenSource != nil || cfg.apiKeyHeader != ""
+
+	if transport == nil && cfg.cookieJar == nil && cfg.redirectPolicy == nil && !authenticated {
+		return http.DefaultClient
+	}
+
+	return &http.Client{Transport: transport, Jar: cfg.cookieJar, CheckRedirect: cfg.checkRedirect}
+}
+
+// Builds the configuration from the given options, and fills any missing value with its default.
+func newConfig(endpoints []string, opts []Option) *config {
+	cfg := &config{endpoints: endpoints, maxResponseBytes: defaultMaxResponseBytes}
+
+	cfg.retry.statuses = make(map[int]bool, len(defaultRetryableStatuses))
+	for _, status := range defaultRetryableStatuses {
+		cfg.retry.statuses[status] = true
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.httpClient == nil {
+		cfg.httpClient = cfg.newHTTPClient()
+	}
+
+	if cfg.breaker != nil {
+		cfg.breaker.window = cfg.breakerWindow
+		cfg.breaker.failureRate = cfg.breakerFailureRate
+	}
+
+	if cfg.retry.backoff == nil {
+		cfg.retry.backoff = ExponentialBackoff{Base: cfg.retry.base}
+	}
+
+	if cfg.clock == nil {
+		cfg.clock = realClock{}
+	}
+
+	if cfg.codec == nil {
+		cfg.codec = jsonCodec{}
+	}
+
+	if cfg.apiVersion == "" {
+		cfg.apiVersion = defaultAPIVersion
+	}
+
+	if cfg.userAgent == "" {
+		cfg.userAgent = defaultUserAgent
+	}
+
+	if cfg.requestIDGenerator == nil {
+		cfg.requestIDGenerator = newUUID
+	}
+
+	return cfg
+}
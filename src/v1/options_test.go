@@ -0,0 +1,204 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// Sets a session cookie on the first request, and records the cookie sent back by the next ones.
+type sessionServer struct {
+	mu sync.Mutex
+
+	// Session cookies received so far, in order. Empty when a request carried none.
+	sessions []string
+}
+
+func (server *sessionServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	session := ""
+	if cookie, err := req.Cookie("session"); err == nil {
+		session = cookie.Value
+	}
+
+	server.mu.Lock()
+	server.sessions = append(server.sessions, session)
+	server.mu.Unlock()
+
+	if session == "" {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "instance-1", Path: "/"})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Returns the session cookies received so far.
+func (server *sessionServer) received() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return append([]string(nil), server.sessions...)
+}
+
+func TestCookieJarEchoesCookies(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create cookie jar: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		opts     []Option
+		jar      http.CookieJar
+		expected []string
+	}{
+		{
+			name:     "CookieJar",
+			opts:     []Option{WithCookieJar(jar)},
+			jar:      jar,
+			expected: []string{"", "instance-1", "instance-1"},
+		},
+		{
+			name:     "StickySession",
+			opts:     []Option{WithStickySession()},
+			expected: []string{"", "instance-1", "instance-1"},
+		},
+		{name: "NoJar", opts: nil, expected: []string{"", "", ""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := &sessionServer{}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			api := NewValidateLogLineAPI(server.URL, testCase.opts...)
+
+			for range len(testCase.expected) {
+				if _, err := api.Call(context.Background(), "a log line"); err != nil {
+					t.Fatalf("failed to validate: %v", err)
+				}
+			}
+
+			got := handler.received()
+			for i, expected := range testCase.expected {
+				if got[i] != expected {
+					t.Errorf("expected request %d to send the session %q, got %q", i+1, expected, got[i])
+				}
+			}
+
+			// The given jar holds the cookies set by the service.
+			if testCase.jar != nil {
+				serverURL, err := url.Parse(server.URL)
+				if err != nil {
+					t.Fatalf("failed to parse the URL of the server: %v", err)
+				}
+
+				if cookies := testCase.jar.Cookies(serverURL); len(cookies) != 1 || cookies[0].Value != "instance-1" {
+					t.Errorf("expected the jar to hold the session cookie, got %v", cookies)
+				}
+			}
+		})
+	}
+}
+
+// Alters the values of the headers of each request in place, once sent, as a careless middleware could.
+type headerMutatingTransport struct {
+	base http.RoundTripper
+}
+
+func (transport headerMutatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := transport.base.RoundTrip(req)
+
+	for _, values := range req.Header {
+		for i := range values {
+			values[i] = "mutated"
+		}
+	}
+
+	return res, err
+}
+
+func TestWithHeadersConcurrentCalls(t *testing.T) {
+	server, sequence := newSequenceServer(t)
+
+	headers := http.Header{"X-Tenant-ID": {"tenant"}, "X-Request-Source": {"worker"}}
+	api := NewCreateLogLineAPI(
+		server.URL, WithHeaders(headers), WithTransport(headerMutatingTransport{base: http.DefaultTransport}),
+	)
+
+	// The client keeps its own copy of the headers.
+	headers.Set("X-Tenant-ID", "another tenant")
+
+	const calls = 20
+
+	var wg sync.WaitGroup
+
+	for range calls {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+				t.Errorf("failed to create a log line: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(sequence.headers) != calls {
+		t.Fatalf("expected %d requests, got %d", calls, len(sequence.headers))
+	}
+
+	for i, received := range sequence.headers {
+		tenant, source := received.Get("X-Tenant-ID"), received.Get("X-Request-Source")
+		if tenant != "tenant" || source != "worker" {
+			t.Errorf("request %d: expected the configured headers, got %q and %q", i, tenant, source)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{name: "Default", expected: "gen-api-proxy/" + Version},
+		{name: "Override", opts: []Option{WithUserAgent("my-service/1.2")}, expected: "my-service/1.2"},
+		// The User-Agent option takes precedence over the default headers.
+		{
+			name:     "OverHeaders",
+			opts:     []Option{WithHeaders(http.Header{"User-Agent": {"from-headers"}}), WithUserAgent("my-service/1.2")},
+			expected: "my-service/1.2",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var userAgent string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				userAgent = req.UserAgent()
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"logLine":"a fake log line"}`))
+			}))
+			defer server.Close()
+
+			api := NewCreateLogLineAPI(server.URL, testCase.opts...)
+			if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			if userAgent != testCase.expected {
+				t.Errorf("expected the User-Agent %q, got %q", testCase.expected, userAgent)
+			}
+		})
+	}
+}
@@ -3,47 +3,288 @@ package v1
 import (
 	"context"
 	"errors"
+	"fmt"
 	gatewayutils "github.com/a-novel/gateway-utils"
 	"net/http"
-	"net/url"
+	"time"
 )
 
+// ErrNotReady is returned by PingAPI.Ready when the service is running, but cannot serve requests yet: it answers the
+// readiness route with a 503 status.
+var ErrNotReady = errors.New("service is not ready")
+
+// PingAPI checks the availability of the Gen-API service. It extends gatewayutils.PingAPI, so it can be used
+// wherever the latter is expected.
+type PingAPI interface {
+	gatewayutils.PingAPI
+	// Live checks that the service is running, using the /ping route. It is the same as Call, and is meant for
+	// liveness probes.
+	Live(ctx context.Context) (int, error)
+	// Ready checks that the service can serve requests, using the /ready route. It is meant for readiness probes.
+	//
+	// A service that is running, but not ready yet, answers with a 503 status: the error then wraps ErrNotReady. As
+	// with Call, the error wraps gatewayutils.ErrUnavailable when the service could not be reached at all. A service
+	// that is not ready is still up, so its endpoint is not marked as down (see WithEndpointFailover).
+	Ready(ctx context.Context) (int, error)
+	// PingWait pings the service until it answers successfully, waiting for interval between two pings. It is meant
+	// for waiting on the service during startup, when it may briefly be unreachable.
+	//
+	// It returns as soon as a ping succeeds. Once the context is done, it returns the status and error of the last
+	// failed ping, joined with the error of the context. It also stops right away if the client is closed.
+	//
+	// The interval must be positive, or an error wrapping ErrInvalidArgument is returned without pinging the service.
+	PingWait(ctx context.Context, interval time.Duration) (int, error)
+	// PingLatency works like Call, and also returns the round-trip time of the request. The latency is only
+	// meaningful when a response was received.
+	PingLatency(ctx context.Context) (time.Duration, int, error)
+	// Monitor pings the service in the background, right away and then every interval, and emits the result of each
+	// ping on the returned channel. It stops, and closes the channel, once the context is done.
+	//
+	// Results are not buffered: a slow reader delays the next ping. The waits between pings follow the clock of the
+	// client (see WithClock).
+	//
+	// The interval must be positive: otherwise, no ping is sent, and the channel only emits a result with an error
+	// wrapping ErrInvalidArgument before being closed.
+	Monitor(ctx context.Context, interval time.Duration) <-chan PingResult
+}
+
+// PingResult is the outcome of a ping emitted by PingAPI.Monitor.
+type PingResult struct {
+	// The status of the response, or 0 if no response was received.
+	Status int
+	Err    error
+	// Round-trip time of the request.
+	Latency time.Duration
+	// When the ping was sent.
+	Time time.Time
+}
+
+// Unavailable reports whether the service could not be reached at all, as opposed to answering with an error.
+func (result PingResult) Unavailable() bool {
+	return errors.Is(result.Err, gatewayutils.ErrUnavailable)
+}
+
 // Implements the PingAPI interface.
 type pingAPI struct {
-	// The root URL for accessing the Gen-API service.
-	endpoint string
+	// Configuration of the client.
+	cfg *config
 }
 
 func (api *pingAPI) Call(ctx context.Context) (int, error) {
-	path, err := url.JoinPath(api.endpoint, "/ping")
+	_, status, err := api.PingLatency(ctx)
+	return status, err
+}
+
+func (api *pingAPI) Live(ctx context.Context) (int, error) {
+	return api.Call(ctx)
+}
+
+func (api *pingAPI) Ready(ctx context.Context) (int, error) {
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.Ready")
+
+	status, err := api.ready(ctx)
+	span.end(status, err)
+
+	return status, err
+}
+
+func (api *pingAPI) ready(ctx context.Context) (int, error) {
+	endpoint, err := api.cfg.endpointFor(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	_, status, err := api.probeEndpoint(ctx, endpoint, "/ready", true)
+	if status == http.StatusServiceUnavailable {
+		return status, errors.Join(ErrNotReady, err)
+	}
+
+	return status, err
+}
+
+func (api *pingAPI) PingLatency(ctx context.Context) (time.Duration, int, error) {
+	ctx, span := api.cfg.startSpan(ctx, "gen-api.Ping")
+
+	latency, status, err := api.call(ctx)
+	span.end(status, err)
+
+	return latency, status, err
+}
+
+func (api *pingAPI) PingWait(ctx context.Context, interval time.Duration) (int, error) {
+	// Without a positive interval, a service that is down would be pinged in a tight loop.
+	if interval <= 0 {
+		return 0, fmt.Errorf("%w: the interval must be positive, got %s", ErrInvalidArgument, interval)
+	}
+
+	var (
+		lastStatus int
+		lastErr    error
+	)
+
+	for {
+		status, err := api.Call(ctx)
+		if err == nil || errors.Is(err, ErrClientClosed) {
+			return status, err
+		}
+
+		// A ping interrupted by the context says nothing about the service: report the last one that completed.
+		if ctx.Err() != nil {
+			if lastErr == nil {
+				return status, err
+			}
+
+			return lastStatus, errors.Join(ctx.Err(), lastErr)
+		}
+
+		lastStatus, lastErr = status, err
+
+		if err := api.cfg.sleep(ctx, interval); err != nil {
+			return lastStatus, errors.Join(err, lastErr)
+		}
+	}
+}
+
+func (api *pingAPI) Monitor(ctx context.Context, interval time.Duration) <-chan PingResult {
+	results := make(chan PingResult)
+
+	go func() {
+		defer close(results)
+
+		// Without a positive interval, the service would be pinged in a tight loop.
+		if interval <= 0 {
+			err := fmt.Errorf("%w: the interval must be positive, got %s", ErrInvalidArgument, interval)
+
+			select {
+			case results <- PingResult{Time: api.cfg.clock.Now(), Err: err}:
+			case <-ctx.Done():
+			}
+
+			return
+		}
+
+		for {
+			result := PingResult{Time: api.cfg.clock.Now()}
+			result.Latency, result.Status, result.Err = api.PingLatency(ctx)
+
+			// The ping fails when the context is done: there is no need to report it.
+			if ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+
+			if err := api.cfg.sleep(ctx, interval); err != nil {
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+func (api *pingAPI) call(ctx context.Context) (time.Duration, int, error) {
+	endpoint, err := api.cfg.endpointFor(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return api.pingEndpoint(ctx, endpoint)
+}
+
+// Pings a specific endpoint. The health of the endpoint is updated with the result.
+func (api *pingAPI) pingEndpoint(ctx context.Context, endpoint string) (time.Duration, int, error) {
+	return api.probeEndpoint(ctx, endpoint, "/ping", false)
+}
+
+// Sends a health check to the given route of an endpoint, which must answer with a 200 status. The health of the
+// endpoint is updated with the result.
+//
+// With notReady set, a 503 status means the service is running but not ready yet: the endpoint is not considered down
+// for it, and keeps its latency.
+func (api *pingAPI) probeEndpoint(
+	ctx context.Context, endpoint, path string, notReady bool,
+) (time.Duration, int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	done, err := api.cfg.calls.start()
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
+	defer done()
+
+	ctx, cancel := api.cfg.withTimeout(ctx)
+	defer cancel()
 
-	res, err := http.DefaultClient.Do(req)
+	ctx = api.cfg.withRequestID(ctx)
+	ctx, timing := api.cfg.startTiming(ctx)
+
+	if err := api.cfg.waitRateLimit(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	req, err := api.cfg.newRequest(ctx, endpoint, http.MethodGet, path, nil)
 	if err != nil {
-		return 0, errors.Join(gatewayutils.ErrUnavailable, err)
+		return 0, 0, err
+	}
+
+	req = timing.trace(req)
+	api.cfg.dumpRequest(req)
+
+	start := api.cfg.clock.Now()
+	res, err := api.cfg.httpClient.Do(req)
+	if res != nil {
+		decompressResponse(res)
+		res.Body = api.cfg.limitBody(res.Body)
 	}
 
-	// If the /ping endpoint returns a non-200 status code, it means the server is running but there is a major
+	api.cfg.dumpResponse(res)
+	latency := api.cfg.clock.Now().Sub(start)
+	timing.endAttempt(res, latency)
+	api.cfg.reportTiming(timing, res)
+	api.cfg.observeRequest(ctx, req, res, err, 1, latency)
+	if err != nil {
+		if ctx.Err() == nil {
+			api.cfg.failover.markDown(endpoint, api.cfg.clock.Now())
+		}
+
+		api.cfg.latencyRouting.forget(endpoint)
+
+		return 0, 0, errors.Join(gatewayutils.ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	// If the health route returns a non-200 status code, it means the server is running but there is a major
 	// issue, preventing it from working normally. This is a case for concern.
 	if err := gatewayutils.EnsureStatus(res, http.StatusOK); err != nil {
-		return res.StatusCode, err
+		if !notReady || res.StatusCode != http.StatusServiceUnavailable {
+			api.cfg.failover.markDown(endpoint, api.cfg.clock.Now())
+			api.cfg.latencyRouting.forget(endpoint)
+		}
+
+		return latency, res.StatusCode, &StatusError{
+			Status: res.StatusCode, Err: err, RequestID: responseRequestID(res),
+		}
 	}
 
-	return res.StatusCode, nil
+	// The service is healthy, there is no need to wait for the cooldown of the breaker.
+	api.cfg.breaker.reset()
+	api.cfg.failover.markUp(endpoint)
+	api.cfg.latencyRouting.observe(endpoint, latency)
+	api.cfg.storeServerVersion(res.Header.Get(serverVersionHeader))
+
+	return latency, res.StatusCode, nil
 }
 
 // NewPingAPI returns a new instance of PingAPI.
 //
 // The endpoint is the root URL for accessing the Gen-API service.
-func NewPingAPI(endpoint string) gatewayutils.PingAPI {
-	return &pingAPI{
-		endpoint: endpoint,
-	}
+func NewPingAPI(endpoint string, opts ...Option) PingAPI {
+	return &pingAPI{cfg: newConfig([]string{endpoint}, opts)}
 }
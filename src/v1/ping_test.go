@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMonitorFollowsClock(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	clock := testutil.NewFakeClock(time.Now())
+	api := NewPingAPI(server.URL, WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := api.Monitor(ctx, time.Minute)
+
+	for i := range 3 {
+		result := <-results
+		if result.Err != nil || result.Status != http.StatusOK {
+			t.Fatalf("expected a successful ping, got %d: %v", result.Status, result.Err)
+		}
+
+		// The next ping waits for the clock.
+		eventually(t, func() bool { return clock.Waiters() == 1 }, "the monitor is not waiting on the clock")
+
+		if got := countRequests(server, http.MethodGet, "/ping"); got != i+1 {
+			t.Fatalf("expected %d pings, got %d", i+1, got)
+		}
+
+		clock.Advance(time.Minute)
+	}
+
+	cancel()
+
+	for range results {
+	}
+}
+
+func TestMonitorInvalidInterval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "Zero", interval: 0},
+		{name: "Negative", interval: -time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			var results []PingResult
+			for result := range NewPingAPI(server.URL).Monitor(context.Background(), testCase.interval) {
+				results = append(results, result)
+			}
+
+			if len(results) != 1 || !errors.Is(results[0].Err, ErrInvalidArgument) {
+				t.Fatalf("expected a single result wrapping ErrInvalidArgument, got %+v", results)
+			}
+
+			if got := countRequests(server, http.MethodGet, "/ping"); got != 0 {
+				t.Errorf("expected no ping, got %d", got)
+			}
+		})
+	}
+}
+
+func TestPingWaitInvalidInterval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "Zero", interval: 0},
+		{name: "Negative", interval: -time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			status, err := NewPingAPI(server.URL).PingWait(context.Background(), testCase.interval)
+			if !errors.Is(err, ErrInvalidArgument) || status != 0 {
+				t.Errorf("expected ErrInvalidArgument, got %d: %v", status, err)
+			}
+
+			if got := countRequests(server, http.MethodGet, "/ping"); got != 0 {
+				t.Errorf("expected no ping, got %d", got)
+			}
+		})
+	}
+}
+
+func TestReadyNotReadyKeepsEndpoint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status int
+		err    error
+		// Whether the endpoint is still healthy, with a known latency, after the call.
+		kept bool
+	}{
+		{name: "NotReady", status: http.StatusServiceUnavailable, err: ErrNotReady, kept: true},
+		{name: "Failure", status: http.StatusInternalServerError, kept: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			server.SetResponse(http.MethodGet, "/ready", testutil.FakeResponse{Status: testCase.status})
+
+			client := NewClient(server.URL, WithEndpointFailover(time.Hour), WithLatencyAwareRouting(time.Minute))
+			client.cfg.latencyRouting.observe(server.URL, time.Millisecond)
+
+			status, err := client.Ping.Ready(context.Background())
+			if err == nil || status != testCase.status {
+				t.Fatalf("expected status %d with an error, got %d: %v", testCase.status, status, err)
+			}
+
+			if testCase.err != nil && !errors.Is(err, testCase.err) {
+				t.Errorf("expected %v, got %v", testCase.err, err)
+			}
+
+			healthy := len(client.HealthyEndpoints()) == 1
+			if healthy != testCase.kept {
+				t.Errorf("expected the endpoint to be healthy: %t, got %t", testCase.kept, healthy)
+			}
+
+			_, known := client.EndpointLatencies()[server.URL]
+			if known != testCase.kept {
+				t.Errorf("expected the latency of the endpoint to be kept: %t, got %t", testCase.kept, known)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package v1
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit limits the rate at which requests are sent to the Gen-API service, to rps requests per second, with
+// bursts of at most burst requests. Each attempt waits for the limiter before being sent, or fails with the error of
+// the context if it is done first.
+//
+// The limiter is shared by all the APIs of a Client. Mock calls never wait for it.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(cfg *config) {
+		cfg.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// Waits until the rate limiter allows a new request, if any.
+func (cfg *config) waitRateLimit(ctx context.Context) error {
+	if cfg.limiter == nil {
+		return nil
+	}
+
+	return cfg.limiter.Wait(ctx)
+}
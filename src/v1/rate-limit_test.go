@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBlocks(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	// A single request is allowed right away, the next one every 100ms.
+	api := NewPingAPI(server.URL, WithRateLimit(10, 1))
+
+	start := time.Now()
+
+	for range 2 {
+		if _, err := api.Call(context.Background()); err != nil {
+			t.Fatalf("failed to ping: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected the second request to wait for the limiter, took %s", elapsed)
+	}
+}
+
+func TestRateLimitCanceled(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	// The burst is spent by the first call, and the next token is far away.
+	api := NewPingAPI(server.URL, WithRateLimit(0.001, 1))
+
+	if _, err := api.Call(context.Background()); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(20*time.Millisecond, cancel)
+	defer timer.Stop()
+
+	errs := make(chan error, 1)
+
+	go func() {
+		_, err := api.Call(ctx)
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call did not stop waiting for the limiter")
+	}
+
+	if got := countRequests(server, http.MethodGet, "/ping"); got != 1 {
+		t.Errorf("expected the canceled call to send nothing, got %d pings", got)
+	}
+}
+
+func TestRateLimitBoundsThroughput(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	// After the burst of 2, the 8 other requests are spread over at least 80ms.
+	api := NewCreateLogLineAPI(server.URL, WithRateLimit(100, 2))
+
+	const calls = 10
+
+	errs := make(chan error, calls)
+	start := time.Now()
+
+	for range calls {
+		go func() {
+			_, _, err := api.Call(context.Background(), "an instruction", nil)
+			errs <- err
+		}()
+	}
+
+	for range calls {
+		if err := <-errs; err != nil {
+			t.Fatalf("failed to create a log line: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("expected %d calls to take at least 70ms, took %s", calls, elapsed)
+	}
+
+	if got := countRequests(server, http.MethodPut, "/api/v1/log-lines"); got != calls {
+		t.Errorf("expected %d requests, got %d", calls, got)
+	}
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// Maximum number of redirects followed by default, as for http.Client.
+const defaultMaxRedirects = 10
+
+// WithRedirectPolicy sets the function deciding whether to follow the redirects of the Gen-API service. It behaves as
+// the CheckRedirect field of http.Client. By default, up to 10 redirects are followed.
+//
+// When a redirect is followed to one of the endpoints of the client, the authentication headers (see WithBearerToken
+// and WithAPIKey) are set again on the redirected request. They are never sent to other hosts, which the redirected
+// request would expose the credentials to. The option is ignored if WithHTTPClient is also given.
+func WithRedirectPolicy(policy func(req *http.Request, via []*http.Request) error) Option {
+	return func(cfg *config) {
+		cfg.redirectPolicy = policy
+	}
+}
+
+// WithNoRedirects stops at the first redirect of the Gen-API service, and returns the redirect response as-is. As for
+// WithRedirectPolicy, it is ignored if WithHTTPClient is also given.
+func WithNoRedirects() Option {
+	return WithRedirectPolicy(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+}
+
+// Implements the CheckRedirect function of the HTTP client built when none is provided.
+func (cfg *config) checkRedirect(req *http.Request, via []*http.Request) error {
+	if cfg.redirectPolicy != nil {
+		if err := cfg.redirectPolicy(req, via); err != nil {
+			return err
+		}
+	} else if len(via) >= defaultMaxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	if !cfg.isEndpointHost(req.URL) {
+		// The HTTP client forwards custom headers to any host, and the authorization header to the other ports of the
+		// same host: remove the credentials explicitly.
+		req.Header.Del("Authorization")
+		if cfg.apiKeyHeader != "" {
+			req.Header.Del(cfg.apiKeyHeader)
+		}
+
+		return nil
+	}
+
+	// The HTTP client drops the authorization header when redirected to another host, even a trusted one.
+	return cfg.authenticate(req)
+}
+
+// Reports whether the URL targets the host of one of the endpoints of the client.
+func (cfg *config) isEndpointHost(target *url.URL) bool {
+	return slices.ContainsFunc(cfg.endpoints, func(endpoint string) bool {
+		parsed, err := url.Parse(endpoint)
+		return err == nil && parsed.Scheme == target.Scheme && parsed.Host == target.Host
+	})
+}
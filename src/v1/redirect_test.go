@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// A server that records the authentication headers of the requests it receives.
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func (recorder *headerRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	recorder.mu.Lock()
+	recorder.headers = append(recorder.headers, req.Header.Clone())
+	recorder.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (recorder *headerRecorder) last(t *testing.T) http.Header {
+	t.Helper()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if len(recorder.headers) == 0 {
+		t.Fatal("expected the server to receive a request")
+	}
+
+	return recorder.headers[len(recorder.headers)-1]
+}
+
+// Returns a server that redirects every request to the given URL.
+func newRedirectServer(target string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, target+req.URL.Path, http.StatusTemporaryRedirect)
+	}))
+}
+
+func TestRedirectToOtherHostDropsCredentials(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	target := httptest.NewServer(recorder)
+	defer target.Close()
+
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	api := NewPingAPI(origin.URL, WithBearerToken("tok"), WithAPIKey("", "secret-key"))
+
+	if _, err := api.Call(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	header := recorder.last(t)
+
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("expected no authorization header, got %q", got)
+	}
+
+	if got := header.Get(defaultAPIKeyHeader); got != "" {
+		t.Errorf("expected no API key header, got %q", got)
+	}
+}
+
+func TestRedirectToEndpointKeepsCredentials(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	target := httptest.NewServer(recorder)
+	defer target.Close()
+
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	client, err := NewClientWithEndpoints(
+		[]string{origin.URL, target.URL}, WithBearerToken("tok"), WithAPIKey("", "secret-key"),
+	)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if _, err := client.Ping.Call(WithEndpointOverride(context.Background(), origin.URL)); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	header := recorder.last(t)
+
+	if got := header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("expected the bearer token, got %q", got)
+	}
+
+	if got := header.Get(defaultAPIKeyHeader); got != "secret-key" {
+		t.Errorf("expected the API key, got %q", got)
+	}
+}
+
+func TestNoRedirects(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	target := httptest.NewServer(recorder)
+	defer target.Close()
+
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	api := NewPingAPI(origin.URL, WithNoRedirects())
+
+	status, err := api.Call(context.Background())
+	if status != http.StatusTemporaryRedirect {
+		t.Errorf("expected the redirect status, got %d", status)
+	}
+
+	if statusErr := new(StatusError); !errors.As(err, &statusErr) {
+		t.Errorf("expected a *StatusError, got %v", err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if len(recorder.headers) != 0 {
+		t.Errorf("expected the redirect not to be followed, got %d requests", len(recorder.headers))
+	}
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithMaxRemix limits the number of remix entries a create call accepts, once normalized. Calls with more entries fail
+// without sending any request. There is no limit by default, or if n is not positive.
+func WithMaxRemix(n int) Option {
+	return func(cfg *config) {
+		cfg.maxRemix = n
+	}
+}
+
+// Cleans the remix entries of a create call: entries are trimmed, and the empty or duplicate ones are dropped, while
+// preserving the order. The result is never nil, so the request always carries a list.
+func (cfg *config) normalizeRemix(remix []string) ([]string, error) {
+	normalized := make([]string, 0, len(remix))
+	seen := make(map[string]struct{}, len(remix))
+
+	for _, entry := range remix {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if _, ok := seen[entry]; ok {
+			continue
+		}
+
+		seen[entry] = struct{}{}
+		normalized = append(normalized, entry)
+	}
+
+	if cfg.maxRemix > 0 && len(normalized) > cfg.maxRemix {
+		return nil, fmt.Errorf(
+			"%w: at most %d remix entries are allowed, got %d", ErrInvalidArgument, cfg.maxRemix, len(normalized),
+		)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// Header used to forward the request ID to the Gen-API service.
+const requestIDHeader = "X-Request-ID"
+
+// Key of the request ID in a context.
+type requestIDKey struct{}
+
+// WithRequestID attaches a request ID to the context. Calls made with this context forward the ID to the Gen-API
+// service, using the X-Request-ID header.
+//
+// When no ID is attached, each call generates a new one. Set the ID beforehand to correlate a call with your own logs,
+// or read the ID of a failed call from its error, with RequestIDOf.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID attached to the context, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithRequestIDGenerator sets the function used to generate request IDs, for calls whose context does not carry one.
+// It defaults to random UUIDs.
+func WithRequestIDGenerator(generator func() string) Option {
+	return func(cfg *config) {
+		cfg.requestIDGenerator = generator
+	}
+}
+
+// Makes sure the context carries a request ID, generating a new one if needed. The ID is set once per call, so every
+// attempt of the same call shares it.
+func (cfg *config) withRequestID(ctx context.Context) context.Context {
+	if _, ok := RequestIDFromContext(ctx); ok {
+		return ctx
+	}
+
+	return WithRequestID(ctx, cfg.requestIDGenerator())
+}
+
+// Returns the ID of the request that got the response: the one sent back by the server, if any, or else the one sent
+// by the client.
+func responseRequestID(res *http.Response) string {
+	if id := res.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+
+	if res.Request == nil {
+		return ""
+	}
+
+	return res.Request.Header.Get(requestIDHeader)
+}
+
+// Generates a random (version 4) UUID.
+func newUUID() string {
+	var id [16]byte
+	// Never returns an error, as stated by the documentation.
+	_, _ = rand.Read(id[:])
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
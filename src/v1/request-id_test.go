@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestRequestIDIsForwarded(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	api := NewPingAPI(server.URL)
+
+	if _, err := api.Call(WithRequestID(context.Background(), "my-request")); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	if got := recorder.last(t).Get(requestIDHeader); got != "my-request" {
+		t.Errorf("expected the request ID of the context, got %q", got)
+	}
+}
+
+func TestRequestIDIsGenerated(t *testing.T) {
+	recorder := new(headerRecorder)
+
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	t.Run("Default", func(t *testing.T) {
+		if _, err := NewPingAPI(server.URL).Call(context.Background()); err != nil {
+			t.Fatalf("ping: %v", err)
+		}
+
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		if got := recorder.last(t).Get(requestIDHeader); !uuid.MatchString(got) {
+			t.Errorf("expected a generated UUID, got %q", got)
+		}
+	})
+
+	t.Run("CustomGenerator", func(t *testing.T) {
+		api := NewPingAPI(server.URL, WithRequestIDGenerator(func() string { return "generated" }))
+
+		if _, err := api.Call(context.Background()); err != nil {
+			t.Fatalf("ping: %v", err)
+		}
+
+		if got := recorder.last(t).Get(requestIDHeader); got != "generated" {
+			t.Errorf("expected the generated request ID, got %q", got)
+		}
+	})
+}
+
+func TestRequestIDOfFailedCall(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	t.Run("Generated", func(t *testing.T) {
+		server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{Status: http.StatusBadRequest})
+
+		api := NewCreateLogLineAPI(server.URL, WithRequestIDGenerator(func() string { return "generated" }))
+
+		_, _, err := api.Call(context.Background(), "an instruction", nil)
+		if id, ok := RequestIDOf(err); !ok || id != "generated" {
+			t.Errorf("expected the generated request ID, got %q (%t)", id, ok)
+		}
+	})
+
+	t.Run("AssignedByServer", func(t *testing.T) {
+		server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{
+			Status: http.StatusBadRequest,
+			Header: http.Header{requestIDHeader: {"from-server"}},
+		})
+
+		api := NewCreateLogLineAPI(server.URL, WithRequestIDGenerator(func() string { return "generated" }))
+
+		_, _, err := api.Call(context.Background(), "an instruction", nil)
+		if id, ok := RequestIDOf(err); !ok || id != "from-server" {
+			t.Errorf("expected the request ID of the server, got %q (%t)", id, ok)
+		}
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		server.SetResponse(http.MethodGet, "/ping", testutil.FakeResponse{Status: http.StatusInternalServerError})
+
+		_, err := NewPingAPI(server.URL).Call(WithRequestID(context.Background(), "my-request"))
+		if id, ok := RequestIDOf(err); !ok || id != "my-request" {
+			t.Errorf("expected the request ID of the context, got %q (%t)", id, ok)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// Bounds the context with the configured timeout, if any. The returned cancel function must be called once the
+// response has been fully consumed.
+func (cfg *config) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cfg.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, cfg.timeout)
+}
+
+// Builds a request to the given path of an endpoint of the Gen-API service, with the configured defaults applied.
+func (cfg *config) newRequest(
+	ctx context.Context, endpoint, method, subPath string, body io.Reader,
+) (*http.Request, error) {
+	path, err := url.JoinPath(endpoint, subPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for key, values := range cfg.headers {
+		if key == "Content-Type" {
+			continue
+		}
+
+		// Copy the values, so concurrent requests don't share the same slices.
+		req.Header[key] = slices.Clone(values)
+	}
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		req.Header.Set(requestIDHeader, id)
+	}
+
+	req.Header.Set("User-Agent", cfg.userAgent)
+
+	// Setting the header disables the transparent decompression of the transport, so the responses are decompressed
+	// by send instead.
+	if cfg.acceptGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	cfg.injectTraceContext(ctx, req)
+
+	if err := cfg.authenticate(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// Returns a requestEditor that sets a header on the request.
+func withHeader(key, value string) requestEditor {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// Customizes a single request, once it has been built with the defaults of the configuration.
+type requestEditor func(req *http.Request)
+
+// Sends a JSON request to the given path of the Gen-API service, and decodes the JSON response.
+//
+// The request is retried according to the configuration, and bounded by the configured timeout. A response with a
+// status other than wantStatus results in an error, described by the body of the response. The response body is not
+// decoded for 204 statuses.
+//
+// The body of the request is omitted if nil. The editors are applied to the request of each attempt.
+func doJSON[Req, Resp any](
+	ctx context.Context, cfg *config, method, subPath string, body Req, wantStatus int, editors ...requestEditor,
+) (Resp, int, error) {
+	var responseBody Resp
+
+	status, err := cfg.doJSON(ctx, method, subPath, body, &responseBody, wantStatus, editors...)
+
+	return responseBody, status, err
+}
+
+// Non-generic implementation of doJSON, that decodes the response into out. The response body is ignored if out is
+// nil.
+func (cfg *config) doJSON(
+	ctx context.Context, method, subPath string, body any, out any, wantStatus int, editors ...requestEditor,
+) (int, error) {
+	done, err := cfg.calls.start()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	if err := cfg.checkServerVersion(ctx); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := cfg.withTimeout(ctx)
+	defer cancel()
+
+	ctx = cfg.withRequestID(ctx)
+
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		if jsonBody, err = cfg.codec.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := cfg.sendBody(ctx, method, subPath, jsonBody, editors...)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// Only a conditional request can be answered with a 304 status.
+	if res.StatusCode == http.StatusNotModified {
+		return res.StatusCode, ErrNotModified
+	}
+
+	if err := gatewayutils.EnsureStatus(res, wantStatus); err != nil {
+		return res.StatusCode, cfg.responseError(res, err)
+	}
+
+	cfg.notifyResponseHeader(res)
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return res.StatusCode, nil
+	}
+
+	if err := cfg.decode(res, out); err != nil {
+		return res.StatusCode, err
+	}
+
+	return res.StatusCode, nil
+}
+
+// Passes the headers of a successful response to the callback of the configuration, if any, and to the call that
+// captures them (see captureResponseHeader).
+func (cfg *config) notifyResponseHeader(res *http.Response) {
+	if cfg.onResponseHeader != nil {
+		cfg.onResponseHeader(res.Header.Clone())
+	}
+
+	if header, ok := res.Request.Context().Value(responseHeaderKey{}).(*http.Header); ok {
+		*header = res.Header.Clone()
+	}
+}
+
+// Sends a request with the given body to the given path of the Gen-API service, and retries it according to the
+// configuration. No body is sent if nil. The editors are applied to the request of each attempt.
+//
+// The body is compressed if configured so (see WithRequestCompression).
+func (cfg *config) sendBody(
+	ctx context.Context, method, subPath string, body []byte, editors ...requestEditor,
+) (*http.Response, error) {
+	ctx, timing := cfg.startTiming(ctx)
+	compressed := cfg.compression.compress(body)
+
+	res, err := cfg.send(ctx, cfg.bodyRequest(ctx, method, subPath, body, compressed, editors))
+	if err == nil && compressed != nil && res.StatusCode == http.StatusUnsupportedMediaType {
+		// The server does not accept compressed bodies: stop compressing them, and send this one again as-is.
+		cfg.compression.rejected.Store(true)
+
+		discardResponse(res)
+
+		res, err = cfg.send(ctx, cfg.bodyRequest(ctx, method, subPath, body, nil, editors))
+	}
+
+	cfg.reportTiming(timing, res)
+
+	return res, err
+}
+
+// Returns a function that builds the request of each attempt of sendBody. The compressed body is sent in place of
+// the original one, unless nil.
+func (cfg *config) bodyRequest(
+	ctx context.Context, method, subPath string, body, compressed []byte, editors []requestEditor,
+) func(endpoint string) (*http.Request, error) {
+	return func(endpoint string) (*http.Request, error) {
+		// A fresh reader is needed for each attempt.
+		var reader io.Reader
+		switch {
+		case compressed != nil:
+			reader = bytes.NewReader(compressed)
+		case body != nil:
+			reader = bytes.NewReader(body)
+		}
+
+		req, err := cfg.newRequest(ctx, endpoint, method, subPath, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if compressed != nil {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		for _, edit := range editors {
+			edit(req)
+		}
+
+		return req, nil
+	}
+}
+
+// Sends a JSON request to the given path of the Gen-API service, and returns the response as-is, whatever its status.
+// The request is retried, and bounded by the timeout, like for doJSON. The timeout is released, and the call is done,
+// once the body of the response is closed.
+func (cfg *config) doRaw(
+	ctx context.Context, method, subPath string, body any, editors ...requestEditor,
+) (*http.Response, error) {
+	done, err := cfg.calls.start()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.checkServerVersion(ctx); err != nil {
+		done()
+		return nil, err
+	}
+
+	ctx, timeoutCancel := cfg.withTimeout(ctx)
+
+	// The call ends once the body of the response is closed.
+	cancel := func() {
+		timeoutCancel()
+		done()
+	}
+
+	ctx = cfg.withRequestID(ctx)
+
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		if jsonBody, err = cfg.codec.Marshal(body); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	res, err := cfg.sendBody(ctx, method, subPath, jsonBody, editors...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+
+	return res, nil
+}
+
+// Releases the context of a request once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
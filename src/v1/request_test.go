@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Starts a server that stalls until the client gives up. If partialBody is true, it first sends the headers and the
+// beginning of a log line, so the client stalls while reading the body. It is closed with the test.
+func newStallingServer(t *testing.T, partialBody bool) *httptest.Server {
+	t.Helper()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if partialBody {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"logLine":`))
+			w.(http.Flusher).Flush()
+		}
+
+		select {
+		case <-req.Context().Done():
+		case <-release:
+		}
+	}))
+
+	// Cleanups run in reverse order: the handlers are released before the server waits for them.
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	return server
+}
+
+func TestWithTimeout(t *testing.T) {
+	testCases := []struct {
+		name        string
+		timeout     time.Duration
+		ctxDeadline time.Duration
+	}{
+		{name: "TimeoutFirst", timeout: 50 * time.Millisecond, ctxDeadline: time.Minute},
+		{name: "ContextFirst", timeout: time.Minute, ctxDeadline: 50 * time.Millisecond},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := newStallingServer(t, false)
+			api := NewCreateLogLineAPI(server.URL, WithTimeout(testCase.timeout))
+
+			ctx, cancel := context.WithTimeout(context.Background(), testCase.ctxDeadline)
+			defer cancel()
+
+			start := time.Now()
+			_, _, err := api.Call(ctx, "an instruction", nil)
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+			}
+
+			// The earlier deadline wins, whichever sets it.
+			if elapsed > 10*time.Second {
+				t.Errorf("expected the call to stop at the earlier deadline, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestWithTimeoutCoversBodyRead(t *testing.T) {
+	server := newStallingServer(t, true)
+	api := NewCreateLogLineAPI(server.URL, WithTimeout(50*time.Millisecond))
+
+	errs := make(chan error, 1)
+
+	go func() {
+		_, _, err := api.Call(context.Background(), "an instruction", nil)
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the timeout did not interrupt the reading of the body")
+	}
+}
+
+func TestWithoutTimeout(t *testing.T) {
+	cfg := newConfig([]string{"http://localhost"}, []Option{WithTimeout(0)})
+
+	ctx, cancel := cfg.withTimeout(context.Background())
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline without a timeout")
+	}
+}
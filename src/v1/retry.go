@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Status codes that are retried by default, when retries are enabled.
+var defaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+}
+
+// Configures how a failed call is retried.
+type retryPolicy struct {
+	// Maximum number of attempts for a single call, including the first one. Calls are not retried if lower than 2.
+	maxAttempts int
+	// Delay before the first retry, for the default backoff. It is doubled after each attempt.
+	base time.Duration
+	// Computes the delay between two attempts. It defaults to an ExponentialBackoff starting at base.
+	backoff Backoff
+	// Longest time spent on a single call, across all its attempts and the waits between them. Not limited if zero.
+	maxElapsed time.Duration
+	// Status codes that trigger a retry.
+	statuses map[int]bool
+	// Decides whether to retry, in place of the status codes. Not used if nil.
+	decider RetryDecider
+	// Called before waiting for each retry. Not used if nil.
+	onRetry func(attempt int, status int, err error, delay time.Duration)
+}
+
+// RetryDecider tells whether a failed attempt should be retried. The status is 0 when no response was received, in
+// which case err is the transport error.
+type RetryDecider func(status int, err error) bool
+
+// WithRetry retries the create and validate calls that fail with a transient error, up to maxAttempts attempts
+// (including the first one).
+//
+// Network errors, as well as 429, 502 and 503 responses, are considered transient (see WithRetryableStatuses and
+// WithRetryDecider to change this). Between two attempts, the client waits for base * 2^attempt, with some jitter
+// (see WithBackoff to change this), unless a 429 response advertises a Retry-After delay. Retries stop as soon as the
+// context of the call is done, or whenever its deadline would be exceeded by the next wait.
+//
+// A 422 response from the validate API is a legitimate answer, and is never retried.
+func WithRetry(maxAttempts int, base time.Duration) Option {
+	return func(cfg *config) {
+		cfg.retry.maxAttempts = maxAttempts
+		cfg.retry.base = base
+	}
+}
+
+// WithMaxElapsedTime limits the time spent on a single call, when retries are enabled (see WithRetry). A failed
+// attempt is not retried if the call would run for longer than maxElapsed by the end of the wait before the next
+// attempt: the outcome of the last attempt is returned instead.
+//
+// It composes with the maximum number of attempts of WithRetry: retries stop as soon as either limit is reached.
+// Unlike a deadline on the context, it never interrupts an attempt in flight.
+func WithMaxElapsedTime(maxElapsed time.Duration) Option {
+	return func(cfg *config) {
+		cfg.retry.maxElapsed = maxElapsed
+	}
+}
+
+// WithRetryableStatuses replaces the status codes that are retried, when retries are enabled. It defaults to 429, 502
+// and 503. Transport errors are still retried.
+//
+// 422 is a legitimate answer of the validate API, and is never retried, even if listed here.
+func WithRetryableStatuses(statuses ...int) Option {
+	return func(cfg *config) {
+		cfg.retry.statuses = make(map[int]bool, len(statuses))
+		for _, status := range statuses {
+			cfg.retry.statuses[status] = true
+		}
+	}
+}
+
+// WithRetryDecider gives full control over which failed attempts are retried, when retries are enabled. The decider
+// is consulted for both the responses with an unexpected status, and the transport errors. It takes precedence over
+// WithRetryableStatuses.
+//
+// A 422 response, or a done context, is never retried, regardless of the decider.
+func WithRetryDecider(decider RetryDecider) Option {
+	return func(cfg *config) {
+		cfg.retry.decider = decider
+	}
+}
+
+// WithOnRetry registers a callback, invoked before waiting for each retry of a failed attempt, when retries are
+// enabled (see WithRetry). It is not called for the first attempt of a call, nor when the failure is not retried.
+//
+// The attempt is the number of the failed attempt, starting at 1. The status is the one of its response, or 0 if no
+// response was received, in which case err is the transport error. The delay is the wait before the next attempt.
+//
+// The callback is called synchronously, from the goroutine making the call, and must not block.
+func WithOnRetry(callback func(attempt int, status int, err error, delay time.Duration)) Option {
+	return func(cfg *config) {
+		cfg.retry.onRetry = callback
+	}
+}
+
+// Indicates whether the outcome of an attempt is worth retrying.
+func (policy retryPolicy) shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	// The context being done means the caller stopped waiting for the result.
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if policy.decider != nil {
+			return policy.decider(0, err)
+		}
+
+		return true
+	}
+
+	return policy.retryableStatus(res.StatusCode)
+}
+
+// Indicates whether a response with the given status is worth retrying.
+func (policy retryPolicy) retryableStatus(status int) bool {
+	// Only error statuses can be retried.
+	if status < http.StatusBadRequest {
+		return false
+	}
+
+	// 422 is the expected answer for invalid log lines, and retrying would yield the same result.
+	if status == http.StatusUnprocessableEntity {
+		return false
+	}
+
+	if policy.decider != nil {
+		return policy.decider(status, nil)
+	}
+
+	return policy.statuses[status]
+}
+
+// Sends the request returned by newRequest, and retries it according to the configured policy.
+//
+// newRequest is called once per attempt, with the endpoint to send the attempt to, so the body of the request can be
+// sent again.
+func (cfg *config) send(
+	ctx context.Context, newRequest func(endpoint string) (*http.Request, error),
+) (*http.Response, error) {
+	// Don't build, nor send anything, if the caller already gave up.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Whether the request was already sent again with a refreshed token (see WithAuthRetry).
+	authRetried := false
+	timing := timingFrom(ctx)
+	start := cfg.clock.Now()
+
+	for attempt := 0; ; attempt++ {
+		if err := cfg.waitRateLimit(ctx); err != nil {
+			return nil, err
+		}
+
+		endpoint, err := cfg.endpointFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := newRequest(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cfg.breaker.allow(cfg.clock.Now()); err != nil {
+			return nil, err
+		}
+
+		req = timing.trace(req)
+		cfg.dumpRequest(req)
+
+		attemptStart := cfg.clock.Now()
+		res, err := cfg.httpClient.Do(req)
+		cfg.breaker.record(cfg.clock.Now(), res, err, ctx.Err() != nil)
+		latency := cfg.clock.Now().Sub(attemptStart)
+		timing.endAttempt(res, latency)
+		cfg.observeRequest(ctx, req, res, err, attempt+1, latency)
+
+		// No response means the service could not be reached, unless the caller gave up.
+		if err != nil && ctx.Err() == nil {
+			err = errors.Join(gatewayutils.ErrUnavailable, err)
+			cfg.failover.markDown(endpoint, cfg.clock.Now())
+			cfg.latencyRouting.forget(endpoint)
+		}
+
+		if res != nil {
+			decompressResponse(res)
+			res.Body = cfg.limitBody(res.Body)
+		}
+
+		cfg.dumpResponse(res)
+
+		if res != nil && res.StatusCode == http.StatusUnauthorized && cfg.shouldRetryAuth(ctx) && !authRetried {
+			// The token may have expired in flight: the next request asks the source for a fresh one. This retry does
+			// not count as an attempt.
+			authRetried = true
+			attempt--
+
+			discardResponse(res)
+
+			continue
+		}
+
+		if attempt+1 >= cfg.retry.maxAttempts || !cfg.retry.shouldRetry(ctx, res, err) {
+			return res, err
+		}
+
+		delay := cfg.retry.backoff.Next(attempt)
+
+		// When rate limited, the server tells exactly how long to wait for.
+		if res != nil && res.StatusCode == http.StatusTooManyRequests {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After"), cfg.clock.Now()); ok {
+				delay = retryAfter
+			}
+		}
+
+		// Don't bother waiting if the call would time out before the next attempt.
+		if deadline, ok := ctx.Deadline(); ok && deadline.Sub(cfg.clock.Now()) < delay {
+			return res, err
+		}
+
+		// Nor if the retry budget would be exhausted by the wait.
+		if cfg.retry.maxElapsed > 0 && cfg.clock.Now().Sub(start)+delay > cfg.retry.maxElapsed {
+			return res, err
+		}
+
+		// The response is discarded in favor of the next attempt.
+		if res != nil {
+			discardResponse(res)
+		}
+
+		if cfg.retry.onRetry != nil {
+			status := 0
+			if res != nil {
+				status = res.StatusCode
+			}
+
+			cfg.retry.onRetry(attempt+1, status, err, delay)
+		}
+
+		if err := cfg.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Reads the rest of the body of a response, then closes it, so its connection can be reused.
+func discardResponse(res *http.Response) {
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+}
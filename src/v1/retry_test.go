@@ -0,0 +1,445 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Answers the requests with a sequence of statuses, then with a generated log line once the sequence is over.
+type statusSequence struct {
+	mu sync.Mutex
+
+	// Statuses of the next responses, in order.
+	statuses []int
+	// Retry-After header of the error responses. Not sent if empty.
+	retryAfter string
+	// Headers of the requests received so far.
+	headers []http.Header
+}
+
+func (sequence *statusSequence) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	sequence.mu.Lock()
+	sequence.headers = append(sequence.headers, req.Header.Clone())
+
+	status := http.StatusOK
+	if len(sequence.statuses) > 0 {
+		status, sequence.statuses = sequence.statuses[0], sequence.statuses[1:]
+	}
+	sequence.mu.Unlock()
+
+	if status != http.StatusOK {
+		if sequence.retryAfter != "" {
+			w.Header().Set("Retry-After", sequence.retryAfter)
+		}
+
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"logLine":"a fake log line"}`))
+}
+
+// Returns the number of requests received so far.
+func (sequence *statusSequence) count() int {
+	sequence.mu.Lock()
+	defer sequence.mu.Unlock()
+
+	return len(sequence.headers)
+}
+
+// Starts a server answering with the given statuses, then with a generated log line. It is closed with the test.
+func newSequenceServer(t *testing.T, statuses ...int) (*httptest.Server, *statusSequence) {
+	t.Helper()
+
+	sequence := &statusSequence{statuses: statuses}
+	server := httptest.NewServer(sequence)
+	t.Cleanup(server.Close)
+
+	return server, sequence
+}
+
+// Advances the clock each time a goroutine waits on it, until the test is done, so the waits of the client end right
+// away.
+func autoAdvance(t *testing.T, clock *testutil.FakeClock) {
+	t.Helper()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				if clock.Waiters() > 0 {
+					clock.Advance(time.Hour)
+				}
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errTransport := errors.New("connection refused")
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		policy   retryPolicy
+		status   int
+		err      error
+		expected bool
+	}{
+		{
+			name:     "TransportError",
+			ctx:      context.Background(),
+			err:      errTransport,
+			expected: true,
+		},
+		{
+			name:     "RetryableStatus",
+			ctx:      context.Background(),
+			policy:   retryPolicy{statuses: map[int]bool{http.StatusServiceUnavailable: true}},
+			status:   http.StatusServiceUnavailable,
+			expected: true,
+		},
+		{
+			name:     "OtherStatus",
+			ctx:      context.Background(),
+			policy:   retryPolicy{statuses: map[int]bool{http.StatusServiceUnavailable: true}},
+			status:   http.StatusInternalServerError,
+			expected: false,
+		},
+		{
+			name:     "InvalidLogLine",
+			ctx:      context.Background(),
+			policy:   retryPolicy{statuses: map[int]bool{http.StatusUnprocessableEntity: true}},
+			status:   http.StatusUnprocessableEntity,
+			expected: false,
+		},
+		{
+			name:     "Success",
+			ctx:      context.Background(),
+			policy:   retryPolicy{decider: func(int, error) bool { return true }},
+			status:   http.StatusOK,
+			expected: false,
+		},
+		{
+			name:     "Decider",
+			ctx:      context.Background(),
+			policy:   retryPolicy{decider: func(status int, _ error) bool { return status == http.StatusConflict }},
+			status:   http.StatusConflict,
+			expected: true,
+		},
+		{
+			name:     "DeciderTransportError",
+			ctx:      context.Background(),
+			policy:   retryPolicy{decider: func(_ int, err error) bool { return !errors.Is(err, errTransport) }},
+			err:      errTransport,
+			expected: false,
+		},
+		{
+			name:     "CanceledContext",
+			ctx:      canceled,
+			policy:   retryPolicy{statuses: map[int]bool{http.StatusServiceUnavailable: true}},
+			status:   http.StatusServiceUnavailable,
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var res *http.Response
+			if testCase.err == nil {
+				res = &http.Response{StatusCode: testCase.status}
+			}
+
+			if got := testCase.policy.shouldRetry(testCase.ctx, res, testCase.err); got != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	server, sequence := newSequenceServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Second), WithClock(clock))
+
+	logLine, status, err := api.Call(context.Background(), "an instruction", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if status != http.StatusOK || logLine != "a fake log line" {
+		t.Errorf("expected the log line of the last attempt, got %d: %q", status, logLine)
+	}
+
+	if got := sequence.count(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	server, sequence := newSequenceServer(
+		t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+	)
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	api := NewCreateLogLineAPI(server.URL, WithRetry(2, time.Second), WithClock(clock))
+
+	_, status, err := api.Call(context.Background(), "an instruction", nil)
+	if code, ok := StatusCodeOf(err); status != http.StatusServiceUnavailable || !ok || code != status {
+		t.Errorf("expected the 503 of the last attempt, got %d: %v", status, err)
+	}
+
+	if got := sequence.count(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryNeverRetriesInvalidLogLine(t *testing.T) {
+	server, sequence := newSequenceServer(t, http.StatusUnprocessableEntity, http.StatusUnprocessableEntity)
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	// Even when listed, 422 is not retried.
+	api := NewValidateLogLineAPI(
+		server.URL,
+		WithRetry(3, time.Second),
+		WithRetryableStatuses(http.StatusUnprocessableEntity),
+		WithClock(clock),
+	)
+
+	status, err := api.Call(context.Background(), "a log line")
+	if status != http.StatusUnprocessableEntity || !errors.Is(err, ErrInvalidLogLine) {
+		t.Errorf("expected ErrInvalidLogLine with a 422 status, got %d: %v", status, err)
+	}
+
+	if got := sequence.count(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestRetryStopsWhenContextIsCanceledDuringBackoff(t *testing.T) {
+	server, sequence := newSequenceServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	// The clock never moves: the call can only end with its context.
+	clock := testutil.NewFakeClock(time.Now())
+	api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Second), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+
+	go func() {
+		_, _, err := api.Call(ctx, "an instruction", nil)
+		errs <- err
+	}()
+
+	eventually(t, func() bool { return clock.Waiters() == 1 }, "the call is not waiting for its retry")
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call did not stop with its context")
+	}
+
+	if got := sequence.count(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+
+	// The wait is given up on, rather than left on the clock.
+	eventually(t, func() bool { return clock.Waiters() == 0 }, "the wait was not given up on")
+}
+
+// An invocation of the callback of WithOnRetry.
+type retryNotice struct {
+	attempt int
+	status  int
+	err     error
+	delay   time.Duration
+}
+
+// Records the invocations of the callback of WithOnRetry.
+type retryNotices struct {
+	mu sync.Mutex
+
+	notices []retryNotice
+}
+
+func (notices *retryNotices) callback(attempt int, status int, err error, delay time.Duration) {
+	notices.mu.Lock()
+	defer notices.mu.Unlock()
+
+	notices.notices = append(notices.notices, retryNotice{attempt: attempt, status: status, err: err, delay: delay})
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		retryAfter string
+		// Expected wait before the retry.
+		expected time.Duration
+	}{
+		{name: "Seconds", retryAfter: "30", expected: 30 * time.Second},
+		{name: "Date", retryAfter: now.Add(45 * time.Second).Format(http.TimeFormat), expected: 45 * time.Second},
+		// Without a usable header, the delay of the backoff is used.
+		{name: "Missing", retryAfter: "", expected: 10 * time.Second},
+		{name: "Invalid", retryAfter: "soon", expected: 10 * time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, sequence := newSequenceServer(t, http.StatusTooManyRequests)
+			sequence.retryAfter = testCase.retryAfter
+
+			clock := testutil.NewFakeClock(now)
+
+			api := NewCreateLogLineAPI(
+				server.URL, WithRetry(2, time.Hour), WithBackoff(ConstantBackoff(10*time.Second)), WithClock(clock),
+			)
+
+			errs := make(chan error, 1)
+
+			go func() {
+				_, _, err := api.Call(context.Background(), "an instruction", nil)
+				errs <- err
+			}()
+
+			eventually(t, func() bool { return clock.Waiters() == 1 }, "the call is not waiting for its retry")
+
+			clock.Advance(testCase.expected - time.Millisecond)
+			if got := sequence.count(); got != 1 {
+				t.Fatalf("expected the retry to wait for %s, got %d attempts", testCase.expected, got)
+			}
+
+			clock.Advance(time.Millisecond)
+
+			if err := <-errs; err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			if got := sequence.count(); got != 2 {
+				t.Errorf("expected 2 attempts, got %d", got)
+			}
+		})
+	}
+}
+
+func TestOnRetry(t *testing.T) {
+	server, _ := newSequenceServer(
+		t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusTooManyRequests,
+	)
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	notices := &retryNotices{}
+	api := NewCreateLogLineAPI(
+		server.URL,
+		WithRetry(4, time.Second),
+		WithBackoff(ConstantBackoff(time.Second)),
+		WithOnRetry(notices.callback),
+		WithClock(clock),
+	)
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	expected := []retryNotice{
+		{attempt: 1, status: http.StatusServiceUnavailable, delay: time.Second},
+		{attempt: 2, status: http.StatusBadGateway, delay: time.Second},
+		{attempt: 3, status: http.StatusTooManyRequests, delay: time.Second},
+	}
+
+	if len(notices.notices) != len(expected) {
+		t.Fatalf("expected %d notices, got %+v", len(expected), notices.notices)
+	}
+
+	for i, notice := range notices.notices {
+		if notice != expected[i] {
+			t.Errorf("expected notice %d to be %+v, got %+v", i+1, expected[i], notice)
+		}
+	}
+}
+
+func TestOnRetryIsNotCalledForLastAttempt(t *testing.T) {
+	server, sequence := newSequenceServer(
+		t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+	)
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	notices := &retryNotices{}
+	api := NewCreateLogLineAPI(server.URL, WithRetry(3, time.Second), WithOnRetry(notices.callback), WithClock(clock))
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+
+	if got := sequence.count(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+
+	if len(notices.notices) != 2 {
+		t.Errorf("expected a notice per retry only, got %+v", notices.notices)
+	}
+}
+
+func TestOnRetryTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	// Nothing listens on the address anymore.
+	server.Close()
+
+	clock := testutil.NewFakeClock(time.Now())
+	autoAdvance(t, clock)
+
+	notices := &retryNotices{}
+	api := NewCreateLogLineAPI(server.URL, WithRetry(2, time.Second), WithOnRetry(notices.callback), WithClock(clock))
+
+	if _, _, err := api.Call(context.Background(), "an instruction", nil); err == nil {
+		t.Fatal("expected the call to fail")
+	}
+
+	if len(notices.notices) != 1 {
+		t.Fatalf("expected a single notice, got %+v", notices.notices)
+	}
+
+	if notice := notices.notices[0]; notice.attempt != 1 || notice.status != 0 || notice.err == nil {
+		t.Errorf("expected the transport error of the first attempt, got %+v", notice)
+	}
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Header used by the Gen-API service to advertise its version, on the responses of the /ping endpoint.
+const serverVersionHeader = "X-Api-Version"
+
+var (
+	// ErrUnknownServerVersion is returned when the Gen-API service does not advertise its version.
+	ErrUnknownServerVersion = errors.New("unknown server version")
+	// ErrUnsupportedServerVersion is returned by the calls to a Gen-API service older than the minimum version set
+	// with WithMinServerVersion.
+	ErrUnsupportedServerVersion = errors.New("unsupported server version")
+)
+
+// WithMinServerVersion makes the calls fail with ErrUnsupportedServerVersion when the Gen-API service is older than
+// the given version, like "1.4" or "v1.4.2". The version of the service is detected on the first call, with
+// Client.ServerVersion. Calls also fail, with ErrUnknownServerVersion, if the service does not advertise its version.
+func WithMinServerVersion(version string) Option {
+	return func(cfg *config) {
+		cfg.minServerVersion = version
+	}
+}
+
+// ServerVersion returns the version of the Gen-API service, as advertised by the X-Api-Version header of its /ping
+// endpoint. The version is detected once, then cached for the life of the client: with multiple endpoints, every one
+// of them is expected to run the same version.
+//
+// An ErrUnknownServerVersion is returned if the service does not advertise its version.
+func (client *Client) ServerVersion(ctx context.Context) (string, error) {
+	return client.cfg.detectServerVersion(ctx)
+}
+
+// Returns the cached version of the service, or pings it to detect the version.
+func (cfg *config) detectServerVersion(ctx context.Context) (string, error) {
+	if version := cfg.serverVersion.Load(); version != nil {
+		return knownServerVersion(*version)
+	}
+
+	// A successful ping caches the version.
+	if _, err := (&pingAPI{cfg: cfg}).Call(ctx); err != nil {
+		return "", fmt.Errorf("detect server version: %w", err)
+	}
+
+	version := cfg.serverVersion.Load()
+	if version == nil {
+		return "", ErrUnknownServerVersion
+	}
+
+	return knownServerVersion(*version)
+}
+
+// Returns the cached version, or ErrUnknownServerVersion if the service was found not to advertise it.
+func knownServerVersion(version string) (string, error) {
+	if version == "" {
+		return "", ErrUnknownServerVersion
+	}
+
+	return version, nil
+}
+
+// Caches the version advertised by a successful response of the /ping endpoint. An empty version is cached as well,
+// so a service that does not advertise its version is not pinged again on every call.
+func (cfg *config) storeServerVersion(version string) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		// Don't forget a version advertised before.
+		cfg.serverVersion.CompareAndSwap(nil, &version)
+		return
+	}
+
+	cfg.serverVersion.Store(&version)
+}
+
+// Checks that the service is recent enough to be called (see WithMinServerVersion).
+func (cfg *config) checkServerVersion(ctx context.Context) error {
+	if cfg.minServerVersion == "" {
+		return nil
+	}
+
+	version, err := cfg.detectServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, cfg.minServerVersion) < 0 {
+		return fmt.Errorf(
+			"%w: server runs version %s, at least %s is required", ErrUnsupportedServerVersion, version,
+			cfg.minServerVersion,
+		)
+	}
+
+	return nil
+}
+
+// Compares two dotted versions, like "1.4.2", numerically. A leading "v" is ignored, as well as any pre-release or
+// build suffix. Missing components count as zero. The result is negative if a < b, positive if a > b, and zero
+// otherwise.
+func compareVersions(a, b string) int {
+	partsA, partsB := versionParts(a), versionParts(b)
+
+	for i := range max(len(partsA), len(partsB)) {
+		var partA, partB int
+		if i < len(partsA) {
+			partA = partsA[i]
+		}
+		if i < len(partsB) {
+			partB = partsB[i]
+		}
+
+		if partA != partB {
+			return partA - partB
+		}
+	}
+
+	return 0
+}
+
+// Splits a version into its numeric components.
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if end := strings.IndexAny(version, "-+"); end >= 0 {
+		version = version[:end]
+	}
+
+	fields := strings.Split(version, ".")
+	parts := make([]int, len(fields))
+
+	for i, field := range fields {
+		// Non-numeric components count as zero.
+		parts[i], _ = strconv.Atoi(field)
+	}
+
+	return parts
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+)
+
+// Counts the requests received on a route of a fake server.
+func countRequests(server *testutil.FakeServer, method, path string) int {
+	count := 0
+
+	for _, req := range server.Requests() {
+		if req.Method == method && req.Path == path {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestMinServerVersion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  string
+		min      string
+		expected error
+	}{
+		{name: "Recent", version: "1.4.2", min: "1.4", expected: nil},
+		{name: "Old", version: "v1.3.9", min: "1.4", expected: ErrUnsupportedServerVersion},
+		{name: "Unknown", version: "", min: "1.4", expected: ErrUnknownServerVersion},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := testutil.NewFakeServer()
+			defer server.Close()
+
+			response := testutil.FakeResponse{Status: http.StatusOK}
+			if testCase.version != "" {
+				response.Header = http.Header{serverVersionHeader: {testCase.version}}
+			}
+
+			server.SetResponse(http.MethodGet, "/ping", response)
+
+			api := NewCreateLogLineAPI(server.URL, WithMinServerVersion(testCase.min))
+
+			for range 3 {
+				if _, _, err := api.Call(context.Background(), "an instruction", nil); !errors.Is(err, testCase.expected) {
+					t.Fatalf("expected %v, got %v", testCase.expected, err)
+				}
+			}
+
+			// The version, known or not, is only detected once.
+			if got := countRequests(server, http.MethodGet, "/ping"); got != 1 {
+				t.Errorf("expected a single ping, got %d", got)
+			}
+		})
+	}
+}
+
+func TestServerVersionKeepsKnownVersion(t *testing.T) {
+	cfg := newConfig([]string{"http://localhost"}, nil)
+
+	cfg.storeServerVersion("1.2")
+	cfg.storeServerVersion("")
+
+	if version, err := cfg.detectServerVersion(context.Background()); err != nil || version != "1.2" {
+		t.Errorf("expected version 1.2, got %q (%v)", version, err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.4", b: "1.4.0", expected: 0},
+		{a: "v1.4.2", b: "1.4", expected: 1},
+		{a: "1.3.9", b: "1.4", expected: -1},
+		{a: "1.10", b: "1.9", expected: 1},
+		{a: "2.0.0-beta", b: "2", expected: 0},
+	}
+
+	for _, testCase := range testCases {
+		got := compareVersions(testCase.a, testCase.b)
+		if (got > 0) != (testCase.expected > 0) || (got < 0) != (testCase.expected < 0) {
+			t.Errorf("compareVersions(%q, %q): expected sign of %d, got %d", testCase.a, testCase.b, testCase.expected, got)
+		}
+	}
+}
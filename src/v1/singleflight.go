@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"golang.org/x/sync/singleflight"
+)
+
+// WithSingleflight makes concurrent create calls with the same instructions, remix and options share a single
+// request to the Gen-API service, so a burst of identical calls only costs one generation. Every caller receives the
+// result of the shared request.
+//
+// A caller that gives up does not cancel the shared request, as long as other callers are still waiting for it. The
+// shared request is still bounded by the deadline of the first caller.
+//
+// Only Call and CallWithOptions are deduplicated: streaming and batch calls always send their own requests. So do the
+// calls whose context overrides the token (WithAuthToken), the endpoint (WithEndpointOverride), carries an idempotency
+// key (WithIdempotencyKey) or asks for a conditional generation (WithIfNoneMatch).
+func WithSingleflight() Option {
+	return func(cfg *config) {
+		cfg.singleflight = new(singleflight.Group)
+	}
+}
+
+// Result of a create request shared by concurrent callers.
+type sharedCreate struct {
+	responseBody createLogLineResponse
+	status       int
+}
+
+// Works like create, but concurrent identical calls share the same request if group is not nil.
+func (api *createLogLineAPI) createShared(
+	ctx context.Context, instruction string, remix []string, opts CreateOptions, group *singleflight.Group,
+) (createLogLineResponse, int, error) {
+	if group == nil || !shareable(ctx) {
+		return api.create(ctx, instruction, remix, opts)
+	}
+
+	key, err := singleflightKey(instruction, remix, opts)
+	if err != nil {
+		return api.create(ctx, instruction, remix, opts)
+	}
+
+	results := group.DoChan(key, func() (any, error) {
+		// The request must outlive the caller that started it, but not its deadline.
+		sharedCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			sharedCtx, cancel = context.WithDeadline(sharedCtx, deadline)
+			defer cancel()
+		}
+
+		responseBody, status, err := api.create(sharedCtx, instruction, remix, opts)
+
+		return sharedCreate{responseBody: responseBody, status: status}, err
+	})
+
+	select {
+	case result := <-results:
+		shared := result.Val.(sharedCreate)
+		return shared.responseBody, shared.status, result.Err
+	case <-ctx.Done():
+		return createLogLineResponse{}, 0, ctx.Err()
+	}
+}
+
+// Tells whether a create call can share its request with other calls. Per-call settings of the context, that change
+// the request, prevent it: the call of another user, or another tenant, must not receive its result, and an
+// idempotency key must be sent with its own request.
+func shareable(ctx context.Context) bool {
+	_, authenticated := authTokenOf(ctx)
+	_, conditional := ifNoneMatch(ctx)
+	override, _ := ctx.Value(endpointOverrideKey{}).(string)
+	idempotencyKey, _ := ctx.Value(idempotencyKeyKey{}).(string)
+
+	return !authenticated && !conditional && override == "" && idempotencyKey == ""
+}
+
+// Identifies the create calls that can share a request.
+func singleflightKey(instruction string, remix []string, opts CreateOptions) (string, error) {
+	content, err := json.Marshal(struct {
+		Instruction string
+		Remix       []string
+		Options     CreateOptions
+	}{instruction, remix, opts})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:]), nil
+}
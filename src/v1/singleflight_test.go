@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Holds the create requests it receives until released, then answers them with a log line.
+type gatedServer struct {
+	mu sync.Mutex
+	// Headers of the requests received so far.
+	headers []http.Header
+
+	// Closed once the requests can be answered.
+	released    chan struct{}
+	releaseOnce sync.Once
+}
+
+func (gate *gatedServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	gate.mu.Lock()
+	gate.headers = append(gate.headers, req.Header.Clone())
+	gate.mu.Unlock()
+
+	select {
+	case <-gate.released:
+	case <-req.Context().Done():
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"logLine":"a fake log line"}`))
+}
+
+// Returns the number of requests received so far.
+func (gate *gatedServer) count() int {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+
+	return len(gate.headers)
+}
+
+// Lets the server answer the requests, held or to come.
+func (gate *gatedServer) release() {
+	gate.releaseOnce.Do(func() { close(gate.released) })
+}
+
+// Starts a gatedServer, that is released and closed with the test.
+func newGatedServer(t *testing.T) (*httptest.Server, *gatedServer) {
+	t.Helper()
+
+	gate := &gatedServer{released: make(chan struct{})}
+	server := httptest.NewServer(gate)
+
+	// Cleanups run in reverse order: the handlers are released before the server waits for them.
+	t.Cleanup(server.Close)
+	t.Cleanup(gate.release)
+
+	return server, gate
+}
+
+// Outcome of a create call.
+type createOutcome struct {
+	logLine string
+	err     error
+}
+
+// Starts a create call in the background, and returns the channel its outcome is sent to.
+func startCreate(ctx context.Context, api CreateLogLineAPI) <-chan createOutcome {
+	outcome := make(chan createOutcome, 1)
+
+	go func() {
+		logLine, _, err := api.Call(ctx, "an instruction", nil)
+		outcome <- createOutcome{logLine: logLine, err: err}
+	}()
+
+	return outcome
+}
+
+// Gives the calls started in the background the time to join the request already in flight. The request is held by
+// the server meanwhile, so a late call would send its own request, and fail the test.
+func waitForJoin() {
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestSingleflightSharesRequest(t *testing.T) {
+	server, gate := newGatedServer(t)
+	api := NewCreateLogLineAPI(server.URL, WithSingleflight())
+
+	// The first call starts the shared request.
+	outcomes := []<-chan createOutcome{startCreate(context.Background(), api)}
+	eventually(t, func() bool { return gate.count() == 1 }, "the shared request was not sent")
+
+	for range 4 {
+		outcomes = append(outcomes, startCreate(context.Background(), api))
+	}
+
+	waitForJoin()
+	gate.release()
+
+	for i, outcome := range outcomes {
+		result := <-outcome
+		if result.err != nil || result.logLine != "a fake log line" {
+			t.Errorf("call %d: expected the shared log line, got %q: %v", i, result.logLine, result.err)
+		}
+	}
+
+	if got := gate.count(); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+}
+
+func TestSingleflightCallerGivesUp(t *testing.T) {
+	server, gate := newGatedServer(t)
+	api := NewCreateLogLineAPI(server.URL, WithSingleflight())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := startCreate(ctx, api)
+	eventually(t, func() bool { return gate.count() == 1 }, "the shared request was not sent")
+
+	second := startCreate(context.Background(), api)
+	waitForJoin()
+
+	// The caller that started the request gives up, while the other one still waits for it.
+	cancel()
+
+	if result := <-first; !errors.Is(result.err, context.Canceled) {
+		t.Errorf("expected the first call to be canceled, got %q: %v", result.logLine, result.err)
+	}
+
+	gate.release()
+
+	if result := <-second; result.err != nil || result.logLine != "a fake log line" {
+		t.Errorf("expected the shared request to complete, got %q: %v", result.logLine, result.err)
+	}
+
+	if got := gate.count(); got != 1 {
+		t.Errorf("expected a single request, got %d", got)
+	}
+}
+
+func TestSingleflightExclusions(t *testing.T) {
+	testCases := []struct {
+		name string
+		// Returns the context of the i-th call.
+		ctx func(i int, endpoint string) context.Context
+	}{
+		{name: "AuthToken", ctx: func(i int, _ string) context.Context {
+			return WithAuthToken(context.Background(), []string{"first-token", "second-token"}[i])
+		}},
+		{name: "EndpointOverride", ctx: func(_ int, endpoint string) context.Context {
+			return WithEndpointOverride(context.Background(), endpoint)
+		}},
+		{name: "IfNoneMatch", ctx: func(_ int, _ string) context.Context {
+			return WithIfNoneMatch(context.Background(), `"etag"`)
+		}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, gate := newGatedServer(t)
+			api := NewCreateLogLineAPI(server.URL, WithSingleflight())
+
+			outcomes := []<-chan createOutcome{
+				startCreate(testCase.ctx(0, server.URL), api),
+				startCreate(testCase.ctx(1, server.URL), api),
+			}
+
+			// Both requests reach the server, while the first one is still held.
+			eventually(t, func() bool { return gate.count() == 2 }, "the calls shared a request")
+			gate.release()
+
+			for i, outcome := range outcomes {
+				if result := <-outcome; result.err != nil {
+					t.Errorf("call %d: failed to create a log line: %v", i, result.err)
+				}
+			}
+		})
+	}
+}
+
+func TestSingleflightSendsEachIdempotencyKey(t *testing.T) {
+	server, gate := newGatedServer(t)
+	api := NewCreateLogLineAPI(server.URL, WithSingleflight())
+
+	first := startCreate(WithIdempotencyKey(context.Background(), "first-key"), api)
+	second := startCreate(WithIdempotencyKey(context.Background(), "second-key"), api)
+
+	eventually(t, func() bool { return gate.count() == 2 }, "the calls shared a request")
+	gate.release()
+	<-first
+	<-second
+
+	gate.mu.Lock()
+	keys := []string{gate.headers[0].Get(idempotencyKeyHeader), gate.headers[1].Get(idempotencyKeyHeader)}
+	gate.mu.Unlock()
+
+	slices.Sort(keys)
+
+	if !slices.Equal(keys, []string{"first-key", "second-key"}) {
+		t.Errorf("expected each call to send its own key, got %v", keys)
+	}
+}
@@ -0,0 +1,122 @@
+// Package testutil provides helpers to test the code that uses the Gen-API client.
+package testutil
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock whose time only moves when told to. It implements the v1.ContextClock interface, so it can be
+// given to v1.WithClock to control the retries, cooldowns and latencies of a client.
+type FakeClock struct {
+	mu sync.Mutex
+
+	// The current time of the clock.
+	now time.Time
+	// Goroutines waiting for the clock to reach a given time.
+	waiters []fakeClockWaiter
+}
+
+// A goroutine waiting on a FakeClock.
+type fakeClockWaiter struct {
+	// When to wake up.
+	until time.Time
+	// Receives the time of the clock on wake up.
+	ch chan time.Time
+	// Stops watching the context of the wait, if any.
+	stop func() bool
+}
+
+// NewFakeClock returns a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (clock *FakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	return clock.now
+}
+
+// Sleep blocks until the clock is advanced by at least the given duration.
+func (clock *FakeClock) Sleep(d time.Duration) {
+	<-clock.After(d)
+}
+
+// After returns a channel that receives the time of the clock, once it is advanced by at least the given duration.
+// The channel receives right away if the duration is not positive.
+func (clock *FakeClock) After(d time.Duration) <-chan time.Time {
+	return clock.AfterContext(context.Background(), d)
+}
+
+// AfterContext works like After, but gives up on the wait once the context is done: the waiter is removed from the
+// clock, and the channel never receives.
+func (clock *FakeClock) AfterContext(ctx context.Context, d time.Duration) <-chan time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- clock.now
+		return ch
+	}
+
+	if ctx.Err() != nil {
+		return ch
+	}
+
+	waiter := fakeClockWaiter{until: clock.now.Add(d), ch: ch, stop: func() bool { return false }}
+	if ctx.Done() != nil {
+		waiter.stop = context.AfterFunc(ctx, func() {
+			clock.remove(ch)
+		})
+	}
+
+	clock.waiters = append(clock.waiters, waiter)
+
+	return ch
+}
+
+// Removes the waiter receiving on the given channel, if it is still waiting.
+func (clock *FakeClock) remove(ch chan time.Time) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	clock.waiters = slices.DeleteFunc(clock.waiters, func(waiter fakeClockWaiter) bool {
+		return waiter.ch == ch
+	})
+}
+
+// Advance moves the clock forward by the given duration, and wakes up the goroutines whose wait is over.
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	clock.now = clock.now.Add(d)
+
+	pending := clock.waiters[:0]
+	for _, waiter := range clock.waiters {
+		if clock.now.Before(waiter.until) {
+			pending = append(pending, waiter)
+			continue
+		}
+
+		waiter.stop()
+		waiter.ch <- clock.now
+	}
+
+	clock.waiters = pending
+}
+
+// Waiters returns the number of goroutines waiting for the clock to advance. Use it to make sure a goroutine reached
+// its wait before calling Advance.
+func (clock *FakeClock) Waiters() int {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	return len(clock.waiters)
+}
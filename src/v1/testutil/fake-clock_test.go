@@ -0,0 +1,98 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+
+	if got := clock.Waiters(); got != 2 {
+		t.Fatalf("expected 2 waiters, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+
+	select {
+	case now := <-short:
+		if !now.Equal(start.Add(time.Second)) {
+			t.Errorf("expected wake up at %v, got %v", start.Add(time.Second), now)
+		}
+	default:
+		t.Fatal("expected the short wait to be over")
+	}
+
+	select {
+	case <-long:
+		t.Fatal("expected the long wait to still be pending")
+	default:
+	}
+
+	if got := clock.Waiters(); got != 1 {
+		t.Errorf("expected 1 waiter, got %d", got)
+	}
+}
+
+func TestFakeClockAfterNonPositive(t *testing.T) {
+	clock := NewFakeClock(time.Time{})
+
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("expected a zero wait to be over right away")
+	}
+
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("expected no waiter, got %d", got)
+	}
+}
+
+func TestFakeClockAfterContextPrunesCancelledWaiters(t *testing.T) {
+	clock := NewFakeClock(time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := clock.AfterContext(ctx, time.Second)
+
+	if got := clock.Waiters(); got != 1 {
+		t.Fatalf("expected 1 waiter, got %d", got)
+	}
+
+	cancel()
+
+	// The waiter is removed asynchronously, once the context is done.
+	deadline := time.Now().Add(time.Second)
+	for clock.Waiters() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the cancelled waiter to be removed, got %d waiters", clock.Waiters())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(time.Minute)
+
+	select {
+	case <-ch:
+		t.Fatal("expected a cancelled wait to never receive")
+	default:
+	}
+}
+
+func TestFakeClockAfterContextDone(t *testing.T) {
+	clock := NewFakeClock(time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_ = clock.AfterContext(ctx, time.Second)
+
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("expected no waiter for a done context, got %d", got)
+	}
+}
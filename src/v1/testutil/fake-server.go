@@ -0,0 +1,172 @@
+package testutil
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+)
+
+// FakeResponse is the answer of a FakeServer on a route.
+type FakeResponse struct {
+	// Status of the response. It defaults to 200.
+	Status int
+	// Headers of the response.
+	Header http.Header
+	// Encoded as JSON in the body of the response. No body is sent if nil.
+	Body any
+}
+
+// FakeServer is an in-memory Gen-API service, so a real v1.Client can be tested through the whole HTTP layer:
+// encoding, headers and status handling.
+//
+// By default, it implements the routes of the service with successful responses:
+//   - GET /ping answers with a 200 status.
+//   - GET /ready answers with a 200 status.
+//   - PUT /api/v1/log-lines answers with a 200 status, and a generated log line.
+//   - POST /api/v1/log-lines answers with a 204 status.
+//   - PUT /api/v1/log-lines/variations answers with a 200 status, and a generated variation.
+//   - POST /api/v1/log-lines/rewrite answers with a 200 status, and a refined log line.
+//   - POST /api/v1/log-lines/score answers with a 200 status, and a score of 0.5.
+//   - POST /api/v1/log-lines/language answers with a 200 status, and detects English.
+//   - POST /api/v1/moderation answers with a 200 status, and does not flag the text.
+//   - POST /api/v1/log-lines/keywords answers with a 200 status, and a single keyword.
+//   - POST /api/v1/log-lines/title answers with a 200 status, and a generated title.
+//   - POST /api/v1/log-lines/translate answers with a 200 status, and a translated log line.
+//   - POST /api/v1/log-lines/jobs answers with a 202 status, and the ID of a job, "fake-job".
+//   - GET /api/v1/log-lines/jobs/fake-job answers with a 200 status, and the log line of the succeeded job.
+//
+// Use SetResponse to change the response of a route. Other routes answer with a 404 status.
+type FakeServer struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	// Responses, indexed by method and path.
+	routes map[fakeRoute]FakeResponse
+	// Requests received by the server.
+	requests []RecordedRequest
+}
+
+// A route of a FakeServer.
+type fakeRoute struct {
+	method string
+	path   string
+}
+
+// NewFakeServer starts a FakeServer. It must be closed once the test is done.
+func NewFakeServer() *FakeServer {
+	server := &FakeServer{
+		routes: map[fakeRoute]FakeResponse{
+			{http.MethodGet, "/ping"}:  {Status: http.StatusOK},
+			{http.MethodGet, "/ready"}: {Status: http.StatusOK},
+			{http.MethodPut, "/api/v1/log-lines"}: {
+				Status: http.StatusOK,
+				Body:   map[string]string{"logLine": "a fake log line"},
+			},
+			{http.MethodPost, "/api/v1/log-lines"}: {Status: http.StatusNoContent},
+			{http.MethodPut, "/api/v1/log-lines/variations"}: {
+				Status: http.StatusOK,
+				Body:   map[string][]string{"logLines": {"a fake variation"}},
+			},
+			{http.MethodPost, "/api/v1/log-lines/rewrite"}: {
+				Status: http.StatusOK,
+				Body:   map[string]string{"logLine": "a fake refined log line"},
+			},
+			{http.MethodPost, "/api/v1/log-lines/score"}: {
+				Status: http.StatusOK,
+				Body:   map[string]float64{"score": 0.5},
+			},
+			{http.MethodPost, "/api/v1/log-lines/language"}: {
+				Status: http.StatusOK,
+				Body:   map[string]any{"lang": "en", "confidence": 0.9},
+			},
+			{http.MethodPost, "/api/v1/moderation"}: {
+				Status: http.StatusOK,
+				Body:   map[string]bool{"flagged": false},
+			},
+			{http.MethodPost, "/api/v1/log-lines/keywords"}: {
+				Status: http.StatusOK,
+				Body:   map[string][]string{"keywords": {"fake"}},
+			},
+			{http.MethodPost, "/api/v1/log-lines/title"}: {
+				Status: http.StatusOK,
+				Body:   map[string]string{"title": "A Fake Title"},
+			},
+			{http.MethodPost, "/api/v1/log-lines/translate"}: {
+				Status: http.StatusOK,
+				Body:   map[string]string{"logLine": "a fake translated log line"},
+			},
+			{http.MethodPost, "/api/v1/log-lines/jobs"}: {
+				Status: http.StatusAccepted,
+				Body:   map[string]string{"jobId": "fake-job"},
+			},
+			{http.MethodGet, "/api/v1/log-lines/jobs/fake-job"}: {
+				Status: http.StatusOK,
+				Body:   map[string]string{"status": "succeeded", "logLine": "a fake log line"},
+			},
+		},
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	return server
+}
+
+// SetResponse sets the response of the server for the given method and path.
+func (server *FakeServer) SetResponse(method, path string, response FakeResponse) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.routes[fakeRoute{method: method, path: path}] = response
+}
+
+// Requests returns the requests received by the server so far, in order.
+func (server *FakeServer) Requests() []RecordedRequest {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return slices.Clone(server.requests)
+}
+
+func (server *FakeServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server.mu.Lock()
+	server.requests = append(server.requests, recorded)
+	response, ok := server.routes[fakeRoute{method: req.Method, path: req.URL.Path}]
+	server.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	maps.Copy(w.Header(), response.Header)
+
+	var body []byte
+	if response.Body != nil {
+		if body, err = json.Marshal(response.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
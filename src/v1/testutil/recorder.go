@@ -0,0 +1,246 @@
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// ErrNoInteraction is returned by a Recorder in replay mode, when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// RecorderMode tells whether a Recorder records new interactions, or replays recorded ones.
+type RecorderMode int
+
+const (
+	// ModeRecord sends the requests to the real service, and records the interactions.
+	ModeRecord RecorderMode = iota
+	// ModeReplay answers the requests with the recorded interactions, without any network access.
+	ModeReplay
+)
+
+// Cassette is the list of interactions recorded by a Recorder, as saved in YAML.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a request sent to the service, and the response it got.
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response"`
+}
+
+// RecordedRequest is the part of a request used to match it with a recorded interaction.
+type RecordedRequest struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Body   string `yaml:"body,omitempty"`
+}
+
+// RecordedResponse is a response recorded by a Recorder.
+type RecordedResponse struct {
+	Status int                 `yaml:"status"`
+	Header map[string][]string `yaml:"header,omitempty"`
+	Body   string              `yaml:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records the interactions with a service in a cassette file, and replays them
+// later. Give it to v1.WithTransport to run tests against a recorded service.
+//
+// Requests are matched with the recorded interactions by method, path and body. In replay mode, each interaction is
+// used once, in the order they were recorded.
+type Recorder struct {
+	mu sync.Mutex
+
+	// Path of the cassette file.
+	path string
+	mode RecorderMode
+	// Sends the requests in record mode.
+	transport http.RoundTripper
+	// Interactions recorded, or to replay.
+	cassette Cassette
+	// Whether each interaction of the cassette was already replayed.
+	replayed []bool
+}
+
+// NewRecorder returns a Recorder using the cassette file at the given path. In record mode, requests are sent with
+// the given transport, or http.DefaultTransport if nil, and the cassette is written by Save. In replay mode, the
+// cassette is read right away.
+func NewRecorder(path string, mode RecorderMode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	recorder := &Recorder{path: path, mode: mode, transport: transport}
+
+	if mode == ModeReplay {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read cassette: %w", err)
+		}
+
+		if err := yaml.Unmarshal(content, &recorder.cassette); err != nil {
+			return nil, fmt.Errorf("decode cassette: %w", err)
+		}
+
+		recorder.replayed = make([]bool, len(recorder.cassette.Interactions))
+	}
+
+	return recorder, nil
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, recorded, err := recordOutgoingRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if recorder.mode == ModeReplay {
+		// The request is not sent, but its body must still be closed, as with any RoundTripper.
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+
+		return recorder.replay(req, recorded)
+	}
+
+	res, err := recorder.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder.mu.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, Interaction{
+		Request:  recorded,
+		Response: RecordedResponse{Status: res.StatusCode, Header: res.Header.Clone(), Body: string(body)},
+	})
+	recorder.mu.Unlock()
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	return res, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing in replay mode.
+func (recorder *Recorder) Save() error {
+	if recorder.mode == ModeReplay {
+		return nil
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	content, err := yaml.Marshal(recorder.cassette)
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+
+	if err := os.WriteFile(recorder.path, content, 0o644); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// Answers the request with the first matching interaction that was not replayed yet.
+func (recorder *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	for i, interaction := range recorder.cassette.Interactions {
+		if recorder.replayed[i] || interaction.Request != recorded {
+			continue
+		}
+
+		recorder.replayed[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header(interaction.Response.Header).Clone(),
+			Body:          io.NopCloser(bytes.NewBufferString(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.Path)
+}
+
+// Reads the parts of an outgoing request used for matching. A RoundTripper must not modify the request it is given:
+// the body is read from a copy when possible. Otherwise, the body is consumed, and the returned clone of the request,
+// with the content of the body restored, must be sent in its place.
+func recordOutgoingRequest(req *http.Request) (*http.Request, RecordedRequest, error) {
+	recorded := RecordedRequest{Method: req.Method, Path: req.URL.Path}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, recorded, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, recorded, fmt.Errorf("copy request body: %w", err)
+		}
+
+		content, err := io.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return nil, recorded, fmt.Errorf("read request body: %w", err)
+		}
+
+		recorded.Body = string(content)
+
+		return req, recorded, nil
+	}
+
+	content, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, recorded, fmt.Errorf("read request body: %w", err)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(content))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	recorded.Body = string(content)
+
+	return clone, recorded, nil
+}
+
+// Reads the parts of a request received by a server, used for matching. The body of the request is restored, so it
+// can still be read.
+func recordRequest(req *http.Request) (RecordedRequest, error) {
+	recorded := RecordedRequest{Method: req.Method, Path: req.URL.Path}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorded, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return recorded, fmt.Errorf("read request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	recorded.Body = string(body)
+
+	return recorded, nil
+}
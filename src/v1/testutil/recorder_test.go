@@ -0,0 +1,183 @@
+package testutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	server := NewFakeServer()
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	recorder, err := NewRecorder(cassette, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+
+	recordedBody := sendThrough(t, recorder, http.MethodPut, server.URL+"/api/v1/log-lines", `{"instruction":"x"}`)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("save cassette: %v", err)
+	}
+
+	// The replayed response must not need the server.
+	server.Close()
+
+	replayer, err := NewRecorder(cassette, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+
+	replayedBody := sendThrough(t, replayer, http.MethodPut, server.URL+"/api/v1/log-lines", `{"instruction":"x"}`)
+	if replayedBody != recordedBody {
+		t.Errorf("expected replayed body %q, got %q", recordedBody, replayedBody)
+	}
+
+	// Each interaction is only replayed once.
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/api/v1/log-lines", strings.NewReader(`{"instruction":"x"}`))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	if _, err := replayer.RoundTrip(req); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction, got %v", err)
+	}
+}
+
+func TestRecorderReplayMatchesBody(t *testing.T) {
+	server := NewFakeServer()
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	recorder, err := NewRecorder(cassette, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+
+	sendThrough(t, recorder, http.MethodPost, server.URL+"/api/v1/log-lines", `{"logLine":"a"}`)
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("save cassette: %v", err)
+	}
+
+	replayer, err := NewRecorder(cassette, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/log-lines", strings.NewReader(`{"logLine":"b"}`))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	if _, err := replayer.RoundTrip(req); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for a different body, got %v", err)
+	}
+}
+
+// Tracks whether a request body was read or closed.
+type trackedBody struct {
+	io.Reader
+	read   bool
+	closed bool
+}
+
+func (body *trackedBody) Read(p []byte) (int, error) {
+	body.read = true
+	return body.Reader.Read(p)
+}
+
+func (body *trackedBody) Close() error {
+	body.closed = true
+	return nil
+}
+
+func TestRecorderDoesNotModifyRequest(t *testing.T) {
+	server := NewFakeServer()
+	defer server.Close()
+
+	recorder, err := NewRecorder(filepath.Join(t.TempDir(), "cassette.yaml"), ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+
+	t.Run("WithGetBody", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/log-lines", bytes.NewBufferString(`{}`))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+
+		body := req.Body
+
+		res, err := recorder.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("round trip: %v", err)
+		}
+		_ = res.Body.Close()
+
+		if req.Body != body {
+			t.Error("expected the body of the request to be left as-is")
+		}
+	})
+
+	t.Run("WithoutGetBody", func(t *testing.T) {
+		body := &trackedBody{Reader: strings.NewReader(`{}`)}
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/log-lines", body)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+
+		res, err := recorder.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("round trip: %v", err)
+		}
+		_ = res.Body.Close()
+
+		if req.Body != io.ReadCloser(body) {
+			t.Error("expected the body of the request to be left as-is")
+		}
+
+		if !body.closed {
+			t.Error("expected the body of the request to be closed")
+		}
+	})
+
+	requests := server.Requests()
+	for _, recorded := range requests {
+		if recorded.Body != `{}` {
+			t.Errorf("expected the server to receive the full body, got %q", recorded.Body)
+		}
+	}
+}
+
+// Sends a request through the recorder, and returns the body of the response.
+func sendThrough(t *testing.T, transport http.RoundTripper, method, url, body string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	defer res.Body.Close()
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+
+	return string(content)
+}
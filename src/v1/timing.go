@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TimingInfo details how long a call took, for latency tracking. See WithTimingCallback.
+type TimingInfo struct {
+	// Time from the start of the first attempt, until the response of the last one was received, including the waits
+	// between attempts. Reading the body of the response is not included.
+	Duration time.Duration
+	// Timings of every request sent for the call, in order.
+	Attempts []AttemptTiming
+	// Status of the final response, or 0 if no response was received.
+	Status int
+}
+
+// AttemptTiming details how long a single request of a call took.
+//
+// The DNS lookup and connection durations are zero when the request reuses an open connection.
+type AttemptTiming struct {
+	// Time spent resolving the host of the endpoint.
+	DNS time.Duration
+	// Time spent establishing the connection, excluding the TLS handshake.
+	Connect time.Duration
+	// Time until the first byte of the response was received, from the start of the request.
+	TTFB time.Duration
+	// Time until the response headers were received, or the request failed.
+	Duration time.Duration
+	// Status of the response, or 0 if no response was received.
+	Status int
+}
+
+// WithTimingCallback calls the given function once per call, after its last response is received, with the timings
+// of the call. This includes failed calls. The callback runs synchronously, so it should be fast.
+//
+// The timings of the network operations are collected with net/http/httptrace, only when this option is set.
+func WithTimingCallback(callback func(TimingInfo)) Option {
+	return func(cfg *config) {
+		cfg.onTiming = callback
+	}
+}
+
+// Key of the timings of a call in a context.
+type callTimingKey struct{}
+
+// Collects the timings of a call. A nil callTiming collects nothing.
+type callTiming struct {
+	// The trace hooks may run on other goroutines than the one sending the request.
+	mu sync.Mutex
+
+	clock Clock
+	// When the call started.
+	start time.Time
+	// Timings of the attempts made so far.
+	attempts []AttemptTiming
+}
+
+// Starts collecting the timings of a call, if a timing callback is configured. The timings are attached to the
+// returned context, so the attempts of the call can record theirs.
+func (cfg *config) startTiming(ctx context.Context) (context.Context, *callTiming) {
+	if cfg.onTiming == nil {
+		return ctx, nil
+	}
+
+	timing := &callTiming{clock: cfg.clock, start: cfg.clock.Now()}
+
+	return context.WithValue(ctx, callTimingKey{}, timing), timing
+}
+
+// Returns the timings collected for the call of the context, if any.
+func timingFrom(ctx context.Context) *callTiming {
+	timing, _ := ctx.Value(callTimingKey{}).(*callTiming)
+	return timing
+}
+
+// Starts a new attempt, and returns the request with hooks recording its network timings.
+func (timing *callTiming) trace(req *http.Request) *http.Request {
+	if timing == nil {
+		return req
+	}
+
+	timing.mu.Lock()
+	timing.attempts = append(timing.attempts, AttemptTiming{})
+	index := len(timing.attempts) - 1
+	timing.mu.Unlock()
+
+	start := timing.clock.Now()
+
+	// Applies a change to the timings of the attempt. The hooks of the trace may be called from the goroutines of the
+	// transport, so all the state they share is guarded by the mutex.
+	update := func(apply func(attempt *AttemptTiming)) {
+		timing.mu.Lock()
+		defer timing.mu.Unlock()
+
+		apply(&timing.attempts[index])
+	}
+
+	var (
+		dnsStart time.Time
+		// Several addresses may be dialed at once (see RFC 6555), so the start of each connection is tracked apart.
+		connectStarts = make(map[string]time.Time)
+	)
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			now := timing.clock.Now()
+			update(func(*AttemptTiming) { dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			now := timing.clock.Now()
+			update(func(attempt *AttemptTiming) { attempt.DNS = now.Sub(dnsStart) })
+		},
+		ConnectStart: func(_, addr string) {
+			now := timing.clock.Now()
+			update(func(*AttemptTiming) { connectStarts[addr] = now })
+		},
+		ConnectDone: func(_, addr string, err error) {
+			now := timing.clock.Now()
+			update(func(attempt *AttemptTiming) {
+				// Only the connection that is used for the request matters.
+				if start, ok := connectStarts[addr]; ok && err == nil {
+					attempt.Connect = now.Sub(start)
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			elapsed := timing.clock.Now().Sub(start)
+			update(func(attempt *AttemptTiming) { attempt.TTFB = elapsed })
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// Ends the last attempt, once its response is received, or it failed.
+func (timing *callTiming) endAttempt(res *http.Response, duration time.Duration) {
+	if timing == nil {
+		return
+	}
+
+	timing.mu.Lock()
+	defer timing.mu.Unlock()
+
+	attempt := &timing.attempts[len(timing.attempts)-1]
+	attempt.Duration = duration
+
+	if res != nil {
+		attempt.Status = res.StatusCode
+	}
+}
+
+// Passes the timings of a call to the callback of the configuration, once its final response is received.
+func (cfg *config) reportTiming(timing *callTiming, res *http.Response) {
+	if timing == nil {
+		return
+	}
+
+	timing.mu.Lock()
+	info := TimingInfo{Duration: cfg.clock.Now().Sub(timing.start), Attempts: slices.Clone(timing.attempts)}
+	timing.mu.Unlock()
+
+	if res != nil {
+		info.Status = res.StatusCode
+	}
+
+	cfg.onTiming(info)
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"context"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimingCallback(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	server.SetResponse(http.MethodPut, "/api/v1/log-lines", testutil.FakeResponse{Status: http.StatusServiceUnavailable})
+
+	var infos []TimingInfo
+
+	api := NewCreateLogLineAPI(
+		server.URL,
+		WithRetry(2, time.Millisecond),
+		WithTimingCallback(func(info TimingInfo) { infos = append(infos, info) }),
+	)
+
+	_, _, _ = api.Call(context.Background(), "an instruction", nil)
+
+	if len(infos) != 1 {
+		t.Fatalf("expected the callback to fire once per call, got %d", len(infos))
+	}
+
+	info := infos[0]
+
+	if info.Status != http.StatusServiceUnavailable {
+		t.Errorf("expected the final status, got %d", info.Status)
+	}
+
+	if len(info.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(info.Attempts))
+	}
+
+	for i, attempt := range info.Attempts {
+		if attempt.Status != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: expected status %d, got %d", i, http.StatusServiceUnavailable, attempt.Status)
+		}
+
+		if attempt.Duration <= 0 || attempt.Duration > info.Duration {
+			t.Errorf("attempt %d: expected a duration within the call, got %s of %s", i, attempt.Duration, info.Duration)
+		}
+	}
+}
+
+func TestTimingTraceConcurrentDials(t *testing.T) {
+	timing := &callTiming{clock: realClock{}, start: time.Now()}
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	trace := httptrace.ContextClientTrace(timing.trace(req).Context())
+
+	// The transport may resolve and dial several addresses at once, from its own goroutines.
+	var wg sync.WaitGroup
+	for _, addr := range []string{"[::1]:80", "127.0.0.1:80"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			trace.DNSStart(httptrace.DNSStartInfo{Host: "localhost"})
+			trace.DNSDone(httptrace.DNSDoneInfo{})
+			trace.ConnectStart("tcp", addr)
+			trace.ConnectDone("tcp", addr, nil)
+		}()
+	}
+
+	wg.Wait()
+	timing.endAttempt(nil, time.Second)
+
+	if len(timing.attempts) != 1 {
+		t.Errorf("expected a single attempt, got %d", len(timing.attempts))
+	}
+}
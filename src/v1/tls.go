@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// WithClientCertificate authenticates the client with the given certificate, for services that require mutual TLS.
+// The option can be repeated to provide multiple certificates.
+//
+// TLS options configure the transport of the HTTP client built by this package. They are ignored if WithHTTPClient is
+// also given, or if WithTransport is given a transport that is not an *http.Transport.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(cfg *config) {
+		cfg.clientCertificates = append(cfg.clientCertificates, cert)
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify the certificate of the Gen-API service, in place of the
+// ones of the system.
+//
+// As for WithClientCertificate, the option is ignored if the HTTP client is provided with WithHTTPClient.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(cfg *config) {
+		cfg.rootCAs = pool
+	}
+}
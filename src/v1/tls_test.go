@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	gatewayutils "github.com/a-novel/gateway-utils"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// A self-signed certificate authority, that issues the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+// Creates a new self-signed certificate authority.
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the key of the CA: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create the certificate of the CA: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate of the CA: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// Issues a certificate for the given usage. Server certificates are valid for the loopback address.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	clientCert := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+	unknownCert := newTestCA(t).issue(t, 4, x509.ExtKeyUsageClientAuth)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{ca.issue(t, 2, x509.ExtKeyUsageServerAuth)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		opts    []Option
+		success bool
+	}{
+		{
+			name:    "ClientCertificate",
+			opts:    []Option{WithRootCAs(ca.pool), WithClientCertificate(clientCert)},
+			success: true,
+		},
+		{name: "NoClientCertificate", opts: []Option{WithRootCAs(ca.pool)}},
+		{name: "UnknownClientCertificate", opts: []Option{WithRootCAs(ca.pool), WithClientCertificate(unknownCert)}},
+		{name: "UnknownServer", opts: []Option{WithClientCertificate(clientCert)}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			api := NewPingAPI(server.URL, testCase.opts...)
+
+			// Each case uses its own transport.
+			defer api.(*pingAPI).cfg.httpClient.CloseIdleConnections()
+
+			status, err := api.Call(context.Background())
+			if testCase.success {
+				if err != nil || status != http.StatusOK {
+					t.Errorf("expected a successful ping, got %d: %v", status, err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, gatewayutils.ErrUnavailable) {
+				t.Errorf("expected the handshake to fail, got %d: %v", status, err)
+			}
+		})
+	}
+}
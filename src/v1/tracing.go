@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"slices"
+)
+
+// Name of the instrumentation library, as reported to the tracer provider.
+const tracerName = "github.com/a-novel/gen-api-proxy/src/v1"
+
+// WithTracerProvider traces every call with OpenTelemetry, using a tracer from the given provider.
+//
+// Each call is recorded in a client span, named after the operation (for example "gen-api.CreateLogLine"). The trace
+// context is forwarded to the Gen-API service using the global propagator.
+//
+// Calls are not traced when no provider is set.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(cfg *config) {
+		if provider == nil {
+			cfg.tracer = nil
+			return
+		}
+
+		cfg.tracer = provider.Tracer(tracerName, trace.WithInstrumentationVersion(Version))
+	}
+}
+
+// Wraps the span of a call. The zero value is a valid no-op span, used when tracing is disabled.
+type callSpan struct {
+	trace.Span
+}
+
+// Starts the span of a call, if tracing is enabled.
+func (cfg *config) startSpan(ctx context.Context, operation string) (context.Context, callSpan) {
+	if cfg.tracer == nil {
+		return ctx, callSpan{}
+	}
+
+	ctx, span := cfg.tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+
+	return ctx, callSpan{Span: span}
+}
+
+// Sets attributes on the span, if tracing is enabled.
+func (span callSpan) setAttributes(attributes ...attribute.KeyValue) {
+	if span.Span == nil {
+		return
+	}
+
+	// Pass a copy, so the variadic slice of the callers does not escape to the heap when tracing is disabled.
+	span.SetAttributes(slices.Clone(attributes)...)
+}
+
+// Records the outcome of the call, and ends the span.
+func (span callSpan) end(status int, err error) {
+	if span.Span == nil {
+		return
+	}
+
+	if status > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Forwards the trace context of the call to the Gen-API service, if tracing is enabled.
+func (cfg *config) injectTraceContext(ctx context.Context, req *http.Request) {
+	if cfg.tracer == nil {
+		return
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A span recorded by a spanRecorder.
+type recordedSpan struct {
+	noop.Span
+
+	name        string
+	kind        trace.SpanKind
+	spanContext trace.SpanContext
+	attributes  []attribute.KeyValue
+	errs        []error
+	status      codes.Code
+	ended       bool
+}
+
+func (span *recordedSpan) SpanContext() trace.SpanContext { return span.spanContext }
+
+func (span *recordedSpan) IsRecording() bool { return !span.ended }
+
+func (span *recordedSpan) SetAttributes(attributes ...attribute.KeyValue) {
+	span.attributes = append(span.attributes, attributes...)
+}
+
+func (span *recordedSpan) RecordError(err error, _ ...trace.EventOption) {
+	span.errs = append(span.errs, err)
+}
+
+func (span *recordedSpan) SetStatus(code codes.Code, _ string) {
+	span.status = code
+}
+
+func (span *recordedSpan) End(...trace.SpanEndOption) {
+	span.ended = true
+}
+
+// Returns the value of an attribute of the span, and whether it was set.
+func (span *recordedSpan) attribute(key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+// A TracerProvider that records the spans started by its tracers.
+type spanRecorder struct {
+	noop.TracerProvider
+
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (recorder *spanRecorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{recorder: recorder}
+}
+
+// Tracer of a spanRecorder.
+type recordingTracer struct {
+	noop.Tracer
+	recorder *spanRecorder
+}
+
+func (tracer recordingTracer) Start(
+	ctx context.Context, name string, opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	recorder := tracer.recorder
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	config := trace.NewSpanStartConfig(opts...)
+
+	span := &recordedSpan{
+		name: name,
+		kind: config.SpanKind(),
+		spanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01},
+			SpanID:     trace.SpanID{byte(len(recorder.spans) + 1)},
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+	recorder.spans = append(recorder.spans, span)
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// Returns the spans started so far.
+func (recorder *spanRecorder) started() []*recordedSpan {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return append([]*recordedSpan(nil), recorder.spans...)
+}
+
+// Uses the W3C trace context propagator for the duration of the test.
+func useTraceContextPropagator(t *testing.T) {
+	t.Helper()
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	useTraceContextPropagator(t)
+
+	testCases := []struct {
+		name     string
+		status   int
+		expected codes.Code
+	}{
+		{name: "Success", status: http.StatusOK, expected: codes.Unset},
+		{name: "Error", status: http.StatusInternalServerError, expected: codes.Error},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, sequence := newSequenceServer(t, testCase.status)
+
+			recorder := new(spanRecorder)
+			api := NewCreateLogLineAPI(server.URL, WithTracerProvider(recorder))
+
+			_, _, err := api.Call(context.Background(), "an instruction", nil)
+			if (err != nil) != (testCase.status != http.StatusOK) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			spans := recorder.started()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+
+			span := spans[0]
+
+			if span.name != "gen-api.CreateLogLine" || span.kind != trace.SpanKindClient || !span.ended {
+				t.Errorf("expected an ended client span named gen-api.CreateLogLine, got %+v", span)
+			}
+
+			length, _ := span.attribute("gen_api.instruction.length")
+			if length.AsInt64() != int64(len("an instruction")) {
+				t.Errorf("expected the length of the instruction, got %v", length.Emit())
+			}
+
+			if status, _ := span.attribute("http.response.status_code"); status.AsInt64() != int64(testCase.status) {
+				t.Errorf("expected the status %d, got %v", testCase.status, status.Emit())
+			}
+
+			if span.status != testCase.expected || (len(span.errs) > 0) != (err != nil) {
+				t.Errorf("expected the status %v, got %v with errors %v", testCase.expected, span.status, span.errs)
+			}
+
+			// The trace context of the span is forwarded to the service.
+			traceParent := sequence.headers[0].Get("Traceparent")
+			if !strings.Contains(traceParent, span.spanContext.TraceID().String()) {
+				t.Errorf("expected the trace context to be forwarded, got %q", traceParent)
+			}
+		})
+	}
+}
+
+func TestWithoutTracerProvider(t *testing.T) {
+	useTraceContextPropagator(t)
+
+	server, sequence := newSequenceServer(t)
+
+	if _, _, err := NewCreateLogLineAPI(server.URL).Call(context.Background(), "an instruction", nil); err != nil {
+		t.Fatalf("failed to create a log line: %v", err)
+	}
+
+	if traceParent := sequence.headers[0].Get("Traceparent"); traceParent != "" {
+		t.Errorf("expected no trace context, got %q", traceParent)
+	}
+
+	cfg := newConfig([]string{"http://localhost"}, nil)
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ctx, span := cfg.startSpan(context.Background(), "gen-api.CreateLogLine")
+		span.setAttributes(attribute.Int("gen_api.instruction.length", 14))
+		cfg.injectTraceContext(ctx, req)
+		span.end(http.StatusOK, nil)
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected no allocation without a tracer provider, got %v", allocs)
+	}
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+)
+
+// WithMaxConnsPerHost limits the number of connections to each endpoint of the Gen-API service, including the ones in
+// use. Requests beyond the limit wait for a connection to be available. There is no limit by default.
+//
+// Transport options configure the transport of the HTTP client built by this package. Like the TLS options, they are
+// ignored if WithHTTPClient is also given, or if WithTransport is given a transport that is not an *http.Transport.
+// When WithTransport is given an *http.Transport, the options apply to a copy of it.
+func WithMaxConnsPerHost(n int) Option {
+	return func(cfg *config) {
+		cfg.maxConnsPerHost = n
+	}
+}
+
+// WithMaxIdleConns limits the number of idle connections kept open, across all the endpoints. As for
+// WithMaxConnsPerHost, it is ignored if the HTTP client is provided with WithHTTPClient.
+func WithMaxIdleConns(n int) Option {
+	return func(cfg *config) {
+		cfg.maxIdleConns = n
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2, which multiplexes the concurrent requests to an endpoint over a single
+// connection. As for WithMaxConnsPerHost, it is ignored if the HTTP client is provided with WithHTTPClient.
+func WithHTTP2(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.http2 = &enabled
+	}
+}
+
+// Returns the transport of the HTTP client built when none is provided, with the TLS and transport options applied.
+// Nil is returned to use http.DefaultTransport.
+func (cfg *config) newTransport() http.RoundTripper {
+	customized := len(cfg.clientCertificates) > 0 || cfg.rootCAs != nil ||
+		cfg.maxConnsPerHost > 0 || cfg.maxIdleConns > 0 || cfg.http2 != nil
+	if !customized {
+		return cfg.transport
+	}
+
+	base, ok := cfg.transport.(*http.Transport)
+	if cfg.transport == nil {
+		base, ok = http.DefaultTransport.(*http.Transport)
+	}
+
+	// Custom round trippers cannot be configured.
+	if !ok {
+		return cfg.transport
+	}
+
+	// Don't alter a transport that may be shared.
+	transport := base.Clone()
+
+	if len(cfg.clientCertificates) > 0 || cfg.rootCAs != nil {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+
+		if len(cfg.clientCertificates) > 0 {
+			tlsConfig.Certificates = cfg.clientCertificates
+		}
+
+		if cfg.rootCAs != nil {
+			tlsConfig.RootCAs = cfg.rootCAs
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if cfg.maxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.maxConnsPerHost
+	}
+
+	if cfg.maxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.maxIdleConns
+	}
+
+	if cfg.http2 != nil {
+		transport.ForceAttemptHTTP2 = *cfg.http2
+		if !*cfg.http2 {
+			// A non-nil, empty map disables HTTP/2.
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+
+			// A transport that already sent requests over HTTP/2 still offers it during the TLS handshake: the server
+			// would then pick a protocol the client does not speak anymore.
+			if transport.TLSClientConfig != nil {
+				transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+				transport.TLSClientConfig.NextProtos = slices.DeleteFunc(
+					slices.Clone(transport.TLSClientConfig.NextProtos),
+					func(protocol string) bool { return protocol == "h2" },
+				)
+			}
+		}
+	}
+
+	return transport
+}
@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Starts a TLS server, with HTTP/2 available, that answers the create requests and records the protocols used.
+func newProtocolServer(tb testing.TB) (*httptest.Server, *x509.CertPool, func() []string) {
+	tb.Helper()
+
+	var (
+		mu        sync.Mutex
+		protocols []string
+	)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		protocols = append(protocols, req.Proto)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"logLine":"a fake log line"}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), protocols...)
+	}
+
+	return server, pool, received
+}
+
+func TestWithHTTP2(t *testing.T) {
+	testCases := []struct {
+		name     string
+		enabled  bool
+		expected string
+	}{
+		{name: "Enabled", enabled: true, expected: "HTTP/2.0"},
+		{name: "Disabled", enabled: false, expected: "HTTP/1.1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server, pool, received := newProtocolServer(t)
+
+			api := NewCreateLogLineAPI(server.URL, WithRootCAs(pool), WithHTTP2(testCase.enabled))
+			defer api.(*createLogLineAPI).cfg.httpClient.CloseIdleConnections()
+
+			if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+				t.Fatalf("failed to create a log line: %v", err)
+			}
+
+			if protocols := received(); len(protocols) != 1 || protocols[0] != testCase.expected {
+				t.Errorf("expected a request over %s, got %v", testCase.expected, protocols)
+			}
+		})
+	}
+}
+
+func TestTransportLimits(t *testing.T) {
+	cfg := newConfig([]string{"http://localhost"}, []Option{WithMaxConnsPerHost(4), WithMaxIdleConns(8)})
+
+	transport, ok := cfg.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", cfg.httpClient.Transport)
+	}
+
+	if transport.MaxConnsPerHost != 4 || transport.MaxIdleConns != 8 {
+		t.Errorf("expected the limits 4 and 8, got %d and %d", transport.MaxConnsPerHost, transport.MaxIdleConns)
+	}
+
+	if transport == http.DefaultTransport {
+		t.Error("expected the default transport to be left untouched")
+	}
+}
+
+func TestTransportIsKeptWithoutCustomization(t *testing.T) {
+	if cfg := newConfig([]string{"http://localhost"}, nil); cfg.httpClient != http.DefaultClient {
+		t.Error("expected the default HTTP client without options")
+	}
+}
+
+// Measures the whole request pipeline of a create call, from encoding to decoding, over HTTP/2 and HTTP/1.1.
+func BenchmarkRequestPipeline(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "HTTP2", enabled: true},
+		{name: "HTTP1", enabled: false},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			server, pool, _ := newProtocolServer(b)
+
+			api := NewCreateLogLineAPI(server.URL, WithRootCAs(pool), WithHTTP2(benchmark.enabled))
+			defer api.(*createLogLineAPI).cfg.httpClient.CloseIdleConnections()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, _, err := api.Call(context.Background(), "an instruction", nil); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithValidationCache caches the results of the validate calls, by log line, for the ttl duration. At most
+// maxEntries results are kept, the least recently used ones being evicted first.
+//
+// Results are only reused by the calls made with the same API version and credentials: a call authenticated with
+// another token (see WithAuthToken) never gets the cached result of someone else.
+//
+// Only the definitive answers of the Gen-API service are cached: a valid log line (204 status), or an invalid one
+// (422 status). Other failures, like network errors, are never cached.
+func WithValidationCache(ttl time.Duration, maxEntries int) Option {
+	return func(cfg *config) {
+		cfg.validationCache = &validationCache{
+			ttl:        ttl,
+			maxEntries: maxEntries,
+			entries:    make(map[validationCacheKey]*list.Element),
+			order:      list.New(),
+		}
+	}
+}
+
+// Identifies the results of the validate calls in the cache.
+type validationCacheKey struct {
+	// Version of the API called.
+	apiVersion string
+	// Hash of the credentials the call is made with.
+	credentials [sha256.Size]byte
+	logLine     string
+}
+
+// Returns the key of the result of a validate call, made with the given context.
+func (cfg *config) validationCacheKey(ctx context.Context, logLine string) validationCacheKey {
+	// The token source of the client is not called here: its tokens all identify the same user.
+	token, _ := authTokenOf(ctx)
+
+	return validationCacheKey{
+		apiVersion:  cfg.apiVersion,
+		credentials: sha256.Sum256([]byte(cfg.apiKeyHeader + "\x00" + cfg.apiKey + "\x00" + token)),
+		logLine:     logLine,
+	}
+}
+
+// Result of a validate call, kept in the cache.
+type validationCacheEntry struct {
+	key    validationCacheKey
+	status int
+	err    error
+	// When the entry stops being valid.
+	expiresAt time.Time
+}
+
+// LRU cache of the results of validate calls. A nil validationCache caches nothing.
+type validationCache struct {
+	mu sync.Mutex
+
+	// How long a result is kept.
+	ttl time.Duration
+	// Maximum number of results kept.
+	maxEntries int
+	// Elements of order, indexed by key.
+	entries map[validationCacheKey]*list.Element
+	// Cached results, from the most to the least recently used.
+	order *list.List
+}
+
+// Returns the cached result of a validation, if any.
+func (cache *validationCache) get(key validationCacheKey, now time.Time) (validationCacheEntry, bool) {
+	if cache == nil {
+		return validationCacheEntry{}, false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return validationCacheEntry{}, false
+	}
+
+	entry := element.Value.(*validationCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		cache.remove(element)
+		return validationCacheEntry{}, false
+	}
+
+	cache.order.MoveToFront(element)
+
+	return *entry, true
+}
+
+// Caches the result of a validation, if it is a definitive answer of the service.
+func (cache *validationCache) set(key validationCacheKey, status int, err error, now time.Time) {
+	if cache == nil || cache.maxEntries < 1 {
+		return
+	}
+
+	definitive := (err == nil && status == http.StatusNoContent) ||
+		(status == http.StatusUnprocessableEntity && errors.Is(err, ErrInvalidLogLine))
+	if !definitive {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry := &validationCacheEntry{key: key, status: status, err: err, expiresAt: now.Add(cache.ttl)}
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(entry)
+
+	for cache.order.Len() > cache.maxEntries {
+		cache.remove(cache.order.Back())
+	}
+}
+
+// Removes every result from the cache.
+func (cache *validationCache) clear() {
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	clear(cache.entries)
+	cache.order.Init()
+}
+
+// Removes an element from the cache. The lock must be held.
+func (cache *validationCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*validationCacheEntry).key)
+}
+
+// ClearValidationCache removes every result from the cache of the validate calls (see WithValidationCache).
+func (client *Client) ClearValidationCache() {
+	client.cfg.validationCache.clear()
+}
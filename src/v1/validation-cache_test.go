@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/a-novel/gen-api-proxy/src/v1/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestValidationCache(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	clock := testutil.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	api := NewValidateLogLineAPI(server.URL, WithValidationCache(time.Minute, 2), WithClock(clock))
+
+	validate := func(ctx context.Context, logLine string) {
+		t.Helper()
+
+		if _, err := api.Call(ctx, logLine); err != nil {
+			t.Fatalf("validate: %v", err)
+		}
+	}
+
+	requests := func() int {
+		return countRequests(server, http.MethodPost, "/api/v1/log-lines")
+	}
+
+	ctx := context.Background()
+
+	validate(ctx, "a")
+	validate(ctx, "a")
+
+	if got := requests(); got != 1 {
+		t.Fatalf("expected the second call to be cached, got %d requests", got)
+	}
+
+	// Another user must not get the cached result.
+	validate(WithAuthToken(ctx, "another-user"), "a")
+
+	if got := requests(); got != 2 {
+		t.Fatalf("expected a call with other credentials not to be cached, got %d requests", got)
+	}
+
+	// The entry of "a" with the default credentials is the least recently used one.
+	validate(ctx, "b")
+	validate(ctx, "a")
+
+	if got := requests(); got != 4 {
+		t.Fatalf("expected the least recently used entry to be evicted, got %d requests", got)
+	}
+
+	clock.Advance(time.Minute)
+	validate(ctx, "a")
+
+	if got := requests(); got != 5 {
+		t.Fatalf("expected the expired entry not to be used, got %d requests", got)
+	}
+
+	api.ClearCache()
+	validate(ctx, "a")
+
+	if got := requests(); got != 6 {
+		t.Fatalf("expected the cleared entry not to be used, got %d requests", got)
+	}
+}
+
+func TestValidationCacheOnlyCachesDefinitiveAnswers(t *testing.T) {
+	server := testutil.NewFakeServer()
+	defer server.Close()
+
+	api := NewValidateLogLineAPI(server.URL, WithValidationCache(time.Minute, 10))
+
+	requests := func() int {
+		return countRequests(server, http.MethodPost, "/api/v1/log-lines")
+	}
+
+	server.SetResponse(http.MethodPost, "/api/v1/log-lines", testutil.FakeResponse{Status: http.StatusUnprocessableEntity})
+
+	for range 2 {
+		if _, err := api.Call(context.Background(), "invalid"); !errors.Is(err, ErrInvalidLogLine) {
+			t.Fatalf("expected ErrInvalidLogLine, got %v", err)
+		}
+	}
+
+	if got := requests(); got != 1 {
+		t.Fatalf("expected an invalid log line to be cached, got %d requests", got)
+	}
+
+	server.SetResponse(http.MethodPost, "/api/v1/log-lines", testutil.FakeResponse{Status: http.StatusServiceUnavailable})
+
+	for range 2 {
+		if _, err := api.Call(context.Background(), "unavailable"); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+
+	if got := requests(); got != 3 {
+		t.Errorf("expected failures not to be cached, got %d requests", got)
+	}
+}
+
+func TestValidationCacheKey(t *testing.T) {
+	defaultVersion := newConfig([]string{"http://localhost"}, []Option{WithAPIKey("", "key")})
+	otherVersion := newConfig([]string{"http://localhost"}, []Option{WithAPIKey("", "key"), WithAPIVersion("v2")})
+	otherKey := newConfig([]string{"http://localhost"}, []Option{WithAPIKey("", "other-key")})
+
+	ctx := context.Background()
+	key := defaultVersion.validationCacheKey(ctx, "a")
+
+	if otherVersion.validationCacheKey(ctx, "a") == key {
+		t.Error("expected the API version to be part of the key")
+	}
+
+	if otherKey.validationCacheKey(ctx, "a") == key {
+		t.Error("expected the API key to be part of the key")
+	}
+
+	if defaultVersion.validationCacheKey(WithAuthToken(ctx, "token"), "a") == key {
+		t.Error("expected the bearer token of the context to be part of the key")
+	}
+
+	if defaultVersion.validationCacheKey(ctx, "a") != key {
+		t.Error("expected the key to be stable")
+	}
+}
@@ -0,0 +1,7 @@
+package v1
+
+// Version of the gen-api-proxy package.
+const Version = "0.1.0"
+
+// User-Agent header sent with every request, unless overridden with WithUserAgent.
+const defaultUserAgent = "gen-api-proxy/" + Version
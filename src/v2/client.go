@@ -0,0 +1,75 @@
+// Package v2 gives access to the v2 routes of the Gen-API service ("/api/v2/..."), with the same client as the v1
+// package.
+//
+// The v2 routes accept the same requests, and return the same responses, as their v1 counterparts, so the APIs of
+// this package are aliases of the v1 ones, configured with v1.WithAPIVersion("v2"). Callers can migrate one API at a
+// time: the options, errors and helpers of the v1 package apply to both.
+//
+//	v1.NewClient              -> v2.NewClient
+//	v1.NewClientWithEndpoints -> v2.NewClientWithEndpoints
+//	v1.NewCreateLogLineAPI    -> v2.NewCreateLogLineAPI
+//	v1.NewValidateLogLineAPI  -> v2.NewValidateLogLineAPI
+//	v1.NewAnalyzeLogLineAPI   -> v2.NewAnalyzeLogLineAPI
+//	v1.NewPingAPI             -> v2.NewPingAPI (the ping route is not versioned)
+//
+// Types are declared in this package only once their v2 shape differs from v1.
+package v2
+
+import (
+	v1 "github.com/a-novel/gen-api-proxy/src/v1"
+)
+
+// Version of the routes of the Gen-API service targeted by this package.
+const APIVersion = "v2"
+
+type (
+	// Option configures the APIs. See the options of the v1 package.
+	Option = v1.Option
+	// Client gives access to every API of the Gen-API service. See v1.Client.
+	Client = v1.Client
+
+	// CreateLogLineAPI generates new log lines. See v1.CreateLogLineAPI.
+	CreateLogLineAPI = v1.CreateLogLineAPI
+	// ValidateLogLineAPI checks whether a text is a valid log line. See v1.ValidateLogLineAPI.
+	ValidateLogLineAPI = v1.ValidateLogLineAPI
+	// AnalyzeLogLineAPI describes existing log lines. See v1.AnalyzeLogLineAPI.
+	AnalyzeLogLineAPI = v1.AnalyzeLogLineAPI
+	// PingAPI checks the availability of the Gen-API service. See v1.PingAPI.
+	PingAPI = v1.PingAPI
+)
+
+// Appends the option selecting the v2 routes, so it takes precedence over any version given by the caller.
+func withAPIVersion(opts []Option) []Option {
+	return append(opts[:len(opts):len(opts)], v1.WithAPIVersion(APIVersion))
+}
+
+// NewClient returns a new Client, that sends its calls to the v2 routes of the Gen-API service. See v1.NewClient.
+func NewClient(endpoint string, opts ...Option) *Client {
+	return v1.NewClient(endpoint, withAPIVersion(opts)...)
+}
+
+// NewClientWithEndpoints returns a new Client, that spreads its calls across multiple replicas of the Gen-API
+// service, on their v2 routes. See v1.NewClientWithEndpoints.
+func NewClientWithEndpoints(endpoints []string, opts ...Option) (*Client, error) {
+	return v1.NewClientWithEndpoints(endpoints, withAPIVersion(opts)...)
+}
+
+// NewCreateLogLineAPI returns a new instance of CreateLogLineAPI, for the v2 routes. See v1.NewCreateLogLineAPI.
+func NewCreateLogLineAPI(endpoint string, opts ...Option) CreateLogLineAPI {
+	return v1.NewCreateLogLineAPI(endpoint, withAPIVersion(opts)...)
+}
+
+// NewValidateLogLineAPI returns a new instance of ValidateLogLineAPI, for the v2 routes. See v1.NewValidateLogLineAPI.
+func NewValidateLogLineAPI(endpoint string, opts ...Option) ValidateLogLineAPI {
+	return v1.NewValidateLogLineAPI(endpoint, withAPIVersion(opts)...)
+}
+
+// NewAnalyzeLogLineAPI returns a new instance of AnalyzeLogLineAPI, for the v2 routes. See v1.NewAnalyzeLogLineAPI.
+func NewAnalyzeLogLineAPI(endpoint string, opts ...Option) AnalyzeLogLineAPI {
+	return v1.NewAnalyzeLogLineAPI(endpoint, withAPIVersion(opts)...)
+}
+
+// NewPingAPI returns a new instance of PingAPI. The ping route is the same for every version. See v1.NewPingAPI.
+func NewPingAPI(endpoint string, opts ...Option) PingAPI {
+	return v1.NewPingAPI(endpoint, withAPIVersion(opts)...)
+}